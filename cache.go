@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+// runCache implements `gophers cache`, which manages the extraction cache.
+func runCache(args []string) {
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Println("Usage: go run . cache prune [flags]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("cache prune", flag.ExitOnError)
+	cacheDir := fs.String("cache-dir", "", "Cache directory (default the user cache directory's gophers folder)")
+	maxAge := fs.Duration("max-age", 0, "Only remove entries unused for this long, e.g. 720h (default remove everything)")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . cache prune [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	dir, err := resolveCacheDir(*cacheDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	removed, err := extractor.PruneCache(dir, *maxAge)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Removed %d cache entries from %s\n", removed, dir)
+}
+
+// resolveCacheDir returns dir, or the default cache directory if dir is empty.
+func resolveCacheDir(dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+	dir, err := extractor.DefaultCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the cache directory: %w", err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+// runDiff implements `gophers diff`, comparing either two graph.json files or
+// the graphs of two git revisions of a local repository.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	repo := fs.String("repo", "", "Extract both graphs from git revisions of the project in this directory")
+	asJSON := fs.Bool("json", false, "Print the diff as JSON")
+	positions := fs.Bool("positions", false, "Report changes of the line and character properties")
+	// With -repo, both revisions are extracted with the same flags as a run
+	flags := addExtractFlags(fs)
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . diff [flags] <old graph.json> <new graph.json>")
+		fmt.Println("       go run . diff [flags] -repo <directory> <old rev> <new rev>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var oldGraph, newGraph *extractor.Graph
+	var err error
+	if *repo != "" {
+		var opts extractor.Options
+		if opts, err = flags.options(); err != nil {
+			log.Fatal(err)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if oldGraph, err = extractor.ExtractRevision(ctx, *repo, fs.Arg(0), opts); err != nil {
+			log.Fatalf("Extraction of %s failed: %v", fs.Arg(0), err)
+		}
+		if newGraph, err = extractor.ExtractRevision(ctx, *repo, fs.Arg(1), opts); err != nil {
+			log.Fatalf("Extraction of %s failed: %v", fs.Arg(1), err)
+		}
+	} else {
+		if oldGraph, err = extractor.LoadGraph(fs.Arg(0)); err != nil {
+			log.Fatal(err)
+		}
+		if newGraph, err = extractor.LoadGraph(fs.Arg(1)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var diffOpts extractor.DiffOptions
+	if !*positions {
+		diffOpts.IgnoreProperties = extractor.PositionProperties
+	}
+	diff := extractor.DiffGraphs(oldGraph, newGraph, diffOpts)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(diff)
+	} else {
+		err = extractor.WriteDiffText(os.Stdout, diff)
+	}
+	if err != nil {
+		log.Fatalf("Failed to write diff: %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/rayhanp1402/gophers/extractor"
@@ -86,6 +88,7 @@ func TestSimplifiedASTBuilder(t *testing.T) {
 		var exp, act interface{}
 		json.Unmarshal(expectedBytes, &exp)
 		json.Unmarshal(actualBytes, &act)
+		act = normalizeURIs(act, inputDir)
 
 		if a, _ := json.Marshal(exp); string(a) != string(jsonMustMarshal(act)) {
 			t.Errorf("mismatch in %s", relPath)
@@ -97,6 +100,40 @@ func TestSimplifiedASTBuilder(t *testing.T) {
 	}
 }
 
+// normalizeURIs makes a decoded simplified AST independent of the machine it
+// was produced on: the project root becomes $PROJECT and GOROOT becomes
+// $GOROOT. Positions inside GOROOT are cleared as they change between Go
+// releases.
+func normalizeURIs(v interface{}, projectRoot string) interface{} {
+	project := "file://" + filepath.ToSlash(projectRoot)
+	goroot := "file://" + filepath.ToSlash(runtime.GOROOT())
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, value := range x {
+			x[key] = normalizeURIs(value, projectRoot)
+		}
+		for _, key := range []string{"uri", "URI"} {
+			uri, ok := x[key].(string)
+			if !ok {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(uri, project):
+				x[key] = "file://$PROJECT" + strings.TrimPrefix(uri, project)
+			case strings.HasPrefix(uri, goroot):
+				x[key] = "file://$GOROOT" + strings.TrimPrefix(uri, goroot)
+				x["Line"], x["Character"] = 0.0, 0.0
+			}
+		}
+	case []interface{}:
+		for i, value := range x {
+			x[i] = normalizeURIs(value, projectRoot)
+		}
+	}
+	return v
+}
+
 func jsonMustMarshal(v interface{}) []byte {
 	b, _ := json.Marshal(v)
 	return b
@@ -0,0 +1,210 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildContext is a target platform and a set of build tags, which together
+// decide which files are part of an extraction. Empty fields fall back to the
+// defaults of the environment, like the go command does.
+type BuildContext struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// ParseBuildContext parses a "GOOS/GOARCH" pair such as "linux/amd64". Either
+// half may be left empty to keep its default.
+func ParseBuildContext(s string, tags []string) (BuildContext, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok {
+		return BuildContext{}, fmt.Errorf("invalid build context %q: want GOOS/GOARCH", s)
+	}
+	return BuildContext{GOOS: goos, GOARCH: goarch, Tags: tags}, nil
+}
+
+// String returns the context as GOOS/GOARCH followed by its tags, e.g.
+// "linux/amd64 tags=integration".
+func (c BuildContext) String() string {
+	ctxt := c.context()
+	s := ctxt.GOOS + "/" + ctxt.GOARCH
+	if len(c.Tags) > 0 {
+		s += " tags=" + strings.Join(c.Tags, ",")
+	}
+	return s
+}
+
+// context returns the go/build context matching c.
+func (c BuildContext) context() build.Context {
+	ctxt := build.Default
+	if c.GOOS != "" && c.GOOS != ctxt.GOOS || c.GOARCH != "" && c.GOARCH != ctxt.GOARCH {
+		// The go command disables cgo when cross-compiling
+		ctxt.CgoEnabled = false
+	}
+	if c.GOOS != "" {
+		ctxt.GOOS = c.GOOS
+	}
+	if c.GOARCH != "" {
+		ctxt.GOARCH = c.GOARCH
+	}
+	ctxt.BuildTags = c.Tags
+	return ctxt
+}
+
+// environ returns the environment go/packages runs the go command with, or
+// nil to inherit the current one.
+func (c BuildContext) environ() []string {
+	if c.GOOS == "" && c.GOARCH == "" {
+		return nil
+	}
+	env := os.Environ()
+	if c.GOOS != "" {
+		env = append(env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		env = append(env, "GOARCH="+c.GOARCH)
+	}
+	return env
+}
+
+// buildFlags returns the flags go/packages passes to the go command.
+func (c BuildContext) buildFlags() []string {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(c.Tags, ",")}
+}
+
+// excludedFiles returns the IDs of the File nodes under root, vendored ones
+// included, whose file name or //go:build line excludes them from c.
+func (c BuildContext) excludedFiles(root string) (map[string]bool, error) {
+	ctxt := c.context()
+	layout, err := newProjectLayout(root)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool)
+
+	visit := func(path string, info os.FileInfo) error {
+		if info.IsDir() {
+			return nil
+		}
+		match, err := ctxt.MatchFile(filepath.Dir(path), filepath.Base(path))
+		if err == nil && !match {
+			excluded[layout.nodeID(path)] = true
+		}
+		return nil
+	}
+	if err := layout.walk(false, visit); err != nil {
+		return nil, err
+	}
+	return excluded, layout.walk(true, visit)
+}
+
+// withoutNodes removes the nodes in ids from graph, along with their edges.
+func withoutNodes(graph *Graph, ids map[string]bool) {
+	if len(ids) == 0 {
+		return
+	}
+	var nodes []GraphNode
+	for _, node := range graph.Elements.Nodes {
+		if !ids[node.Data.ID] {
+			nodes = append(nodes, node)
+		}
+	}
+	var edges []GraphEdge
+	for _, edge := range graph.Elements.Edges {
+		if !ids[edge.Data.Source] && !ids[edge.Data.Target] {
+			edges = append(edges, edge)
+		}
+	}
+	graph.Elements.Nodes, graph.Elements.Edges = nodes, edges
+}
+
+// extractContexts extracts dir once for every build context of opts,
+// concurrently, and merges the graphs. Only the last context writes debug
+// output.
+func extractContexts(ctx context.Context, dir string, opts Options) (*Graph, error) {
+	names := make([]string, len(opts.BuildContexts))
+	graphs := make([]*Graph, len(opts.BuildContexts))
+	err := parallel(ctx, opts.workers(), len(opts.BuildContexts), func(i int) error {
+		buildContext := opts.BuildContexts[i]
+		single := opts
+		single.BuildContexts = []BuildContext{buildContext}
+		if i < len(opts.BuildContexts)-1 {
+			single.IntermediateDir, single.SymbolTableFile = "", ""
+		}
+		graph, err := Extract(ctx, dir, single)
+		if err != nil {
+			return fmt.Errorf("build context %s: %w", buildContext, err)
+		}
+		names[i] = buildContext.String()
+		graphs[i] = graph
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return MergeGraphs(names, graphs), nil
+}
+
+// MergeGraphs unites graphs extracted for different build contexts, named by
+// contexts. Nodes and edges are matched by ID and keep the labels and
+// properties of the first graph they appear in. Their "buildContexts"
+// property lists, separated by ";", the contexts whose graph contains them.
+func MergeGraphs(contexts []string, graphs []*Graph) *Graph {
+	merged := &Graph{}
+	nodes := make(map[string]*GraphNode)
+	edges := make(map[string]*GraphEdge)
+	var nodeOrder, edgeOrder []string
+
+	for i, graph := range graphs {
+		for _, node := range graph.Elements.Nodes {
+			existing, ok := nodes[node.Data.ID]
+			if !ok {
+				node.Data.Properties = withBuildContext(node.Data.Properties, "", contexts[i])
+				nodes[node.Data.ID] = &node
+				nodeOrder = append(nodeOrder, node.Data.ID)
+				continue
+			}
+			existing.Data.Properties = withBuildContext(existing.Data.Properties, existing.Data.Properties["buildContexts"], contexts[i])
+		}
+		for _, edge := range graph.Elements.Edges {
+			existing, ok := edges[edge.Data.ID]
+			if !ok {
+				edge.Data.Properties = withBuildContext(edge.Data.Properties, "", contexts[i])
+				edges[edge.Data.ID] = &edge
+				edgeOrder = append(edgeOrder, edge.Data.ID)
+				continue
+			}
+			existing.Data.Properties = withBuildContext(existing.Data.Properties, existing.Data.Properties["buildContexts"], contexts[i])
+		}
+	}
+
+	for _, id := range nodeOrder {
+		merged.Elements.Nodes = append(merged.Elements.Nodes, *nodes[id])
+	}
+	for _, id := range edgeOrder {
+		merged.Elements.Edges = append(merged.Elements.Edges, *edges[id])
+	}
+	return merged
+}
+
+// withBuildContext returns a copy of properties whose "buildContexts" is
+// contexts with context appended.
+func withBuildContext(properties map[string]string, contexts, context string) map[string]string {
+	updated := make(map[string]string, len(properties)+1)
+	for key, value := range properties {
+		updated[key] = value
+	}
+	if contexts != "" {
+		context = contexts + ";" + context
+	}
+	updated["buildContexts"] = context
+	return updated
+}
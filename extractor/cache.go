@@ -0,0 +1,474 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion changes whenever the simplified AST, the symbol table or the
+// graph change shape, so that entries written by older versions of Gophers
+// are never reused.
+const cacheVersion = "3"
+
+// metadataMode is enough to compute cache keys: the files and project
+// imports of every package, but no syntax or types.
+const metadataMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedModule
+
+// DefaultCacheDir returns the cache directory of the command line tool,
+// gophers under the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gophers"), nil
+}
+
+// toolVersion identifies the build of Gophers writing cache entries and the
+// Go toolchain, as reported by goVersion, that type-checks the project.
+func toolVersion(goVersion string) string {
+	version := cacheVersion + " " + goVersion
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version += " " + info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+				version += " " + setting.Value
+			}
+		}
+	}
+	return version
+}
+
+// goVersion returns the version and GOROOT of the go command that loads the
+// project in dir. It is not necessarily the toolchain Gophers was built
+// with: go.mod may select another one, and the standard library the project
+// is type-checked against comes from GOROOT.
+func goVersion(ctx context.Context, dir string, opts Options) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOVERSION", "GOROOT")
+	cmd.Dir = dir
+	cmd.Env = opts.buildContext().environ()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.Join(strings.Fields(string(out)), " "), nil
+}
+
+// hashStrings returns the hex SHA-256 of parts, each terminated by a newline.
+func hashStrings(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		io.WriteString(h, part)
+		io.WriteString(h, "\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFile returns the hex SHA-256 of the file at path, or "" if it does not
+// exist.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// packageEntry is what the cache keeps per package: the simplified AST of
+// each of its files, keyed by absolute path, and the part of the graph the
+// package contributes. Extractions with a call graph only keep the ASTs.
+type packageEntry struct {
+	PkgPath string                        `json:"pkgPath"`
+	Files   map[string]*SimplifiedASTNode `json:"files"`
+	Graph   *packageGraph                 `json:"graph,omitempty"`
+}
+
+// extractionCache is the content-addressed cache of one extraction. Packages
+// are keyed by the content of their files, the go.mod and go.sum of their
+// module, the versions of Gophers and Go and the options, and by the keys of
+// the project packages they import; a change therefore also invalidates every
+// package that depends on the changed one. The graph is keyed by all of that
+// plus the layout of the project tree.
+type extractionCache struct {
+	dir         string
+	pkgs        []*packages.Package // without syntax or types
+	byPath      map[string]*packages.Package
+	packageKeys map[string]string // by package ID
+	graphKey    string
+	entries     map[string]*packageEntry // loaded so far, by package ID
+}
+
+// newExtractionCache computes the keys, within the cache in cacheDir, of the
+// project at absPath without type-checking it.
+func newExtractionCache(ctx context.Context, cacheDir, absPath string, opts Options) (*extractionCache, error) {
+	pkgs, err := loadPackagesMode(ctx, nil, absPath, nil, opts, metadataMode, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := goVersion(ctx, absPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	salt := hashStrings(toolVersion(version), opts.fingerprint())
+	moduleHashes := map[string]string{}
+	moduleHash := func(pkg *packages.Package) (string, error) {
+		moduleDir := absPath
+		if pkg.Module != nil && pkg.Module.Dir != "" {
+			moduleDir = pkg.Module.Dir
+		}
+		if hash, ok := moduleHashes[moduleDir]; ok {
+			return hash, nil
+		}
+		var parts []string
+		for _, name := range []string{"go.mod", "go.sum", filepath.Join("vendor", "modules.txt")} {
+			hash, err := hashFile(filepath.Join(moduleDir, name))
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, name+" "+hash)
+		}
+		moduleHashes[moduleDir] = hashStrings(parts...)
+		return moduleHashes[moduleDir], nil
+	}
+
+	// Imports are looked up by path: with tests, a package may import the
+	// plain variant of a package that is only kept as its test variant
+	c := &extractionCache{
+		dir:         cacheDir,
+		pkgs:        pkgs,
+		byPath:      make(map[string]*packages.Package, len(pkgs)),
+		packageKeys: make(map[string]string, len(pkgs)),
+		entries:     make(map[string]*packageEntry),
+	}
+	for _, pkg := range pkgs {
+		c.byPath[pkg.PkgPath] = pkg
+	}
+
+	var keyOf func(pkg *packages.Package, visiting map[string]bool) (string, error)
+	keyOf = func(pkg *packages.Package, visiting map[string]bool) (string, error) {
+		if key, ok := c.packageKeys[pkg.ID]; ok {
+			return key, nil
+		}
+		if visiting[pkg.ID] {
+			return "", fmt.Errorf("import cycle through %s", pkg.PkgPath)
+		}
+		visiting[pkg.ID] = true
+
+		modHash, err := moduleHash(pkg)
+		if err != nil {
+			return "", err
+		}
+		parts := []string{salt, pkg.ID, modHash}
+		for _, file := range pkg.CompiledGoFiles {
+			hash, err := hashFile(file)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, file+" "+hash)
+		}
+
+		// Project imports contribute their keys; the others are covered by
+		// go.sum and the Go version
+		for _, path := range sortedKeys(pkg.Imports) {
+			dep, ok := c.byPath[pkg.Imports[path].PkgPath]
+			if !ok {
+				continue
+			}
+			depKey, err := keyOf(dep, visiting)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, path+" "+depKey)
+		}
+
+		key := hashStrings(parts...)
+		c.packageKeys[pkg.ID] = key
+		return key, nil
+	}
+	for _, pkg := range pkgs {
+		if _, err := keyOf(pkg, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Folder and File nodes come from the tree, which holds more than the
+	// packages' files, e.g. files excluded by build constraints
+	graphParts := []string{salt}
+	layout, err := newProjectLayout(absPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, vendor := range []bool{false, true} {
+		err := layout.walk(vendor, func(path string, info os.FileInfo) error {
+			graphParts = append(graphParts, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range []string{"go.work", "go.work.sum"} {
+		hash, err := hashFile(filepath.Join(absPath, name))
+		if err != nil {
+			return nil, err
+		}
+		graphParts = append(graphParts, name+" "+hash)
+	}
+	for _, mod := range layout.modules {
+		hash, err := hashFile(filepath.Join(mod.Dir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		graphParts = append(graphParts, mod.Dir+" "+hash)
+	}
+	for _, id := range sortedKeys(c.packageKeys) {
+		graphParts = append(graphParts, c.packageKeys[id])
+	}
+	c.graphKey = hashStrings(graphParts...)
+
+	return c, nil
+}
+
+// fingerprint lists the options that change what Extract produces.
+func (opts Options) fingerprint() string {
+	return strings.Join([]string{
+		opts.buildContext().String(),
+		opts.CallGraph,
+		strconv.FormatBool(opts.Inherits),
+		strconv.FormatBool(opts.Tests),
+		strconv.Itoa(max(opts.TestDepth, 1)),
+		strconv.FormatBool(opts.Vendor),
+		strconv.FormatBool(opts.External),
+	}, " ")
+}
+
+// entryPath returns where the entry of kind with key is stored.
+func (c *extractionCache) entryPath(kind, key string) string {
+	return filepath.Join(c.dir, kind, key[:2], key+".json")
+}
+
+// load decodes the entry of kind with key into v and reports whether it
+// exists. Reading an entry marks it as used for PruneCache.
+func (c *extractionCache) load(kind, key string, v any) bool {
+	path := c.entryPath(kind, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return true
+}
+
+// store writes v as the entry of kind with key. The entry is written to a
+// temporary file first so that concurrent runs never read half an entry.
+func (c *extractionCache) store(kind, key string, v any) error {
+	path := c.entryPath(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// graph returns the cached graph of the extraction, if any.
+func (c *extractionCache) graph() (*Graph, bool) {
+	var graph Graph
+	if !c.load("graphs", c.graphKey, &graph) {
+		return nil, false
+	}
+	return &graph, true
+}
+
+// storeGraph caches the graph of the extraction.
+func (c *extractionCache) storeGraph(graph *Graph) error {
+	return c.store("graphs", c.graphKey, graph)
+}
+
+// entry returns the cached entry of pkg, if any. A package that is not part
+// of the project as loaded for the keys, like the plain variant of a package
+// kept as its test variant, has no entry.
+func (c *extractionCache) entry(pkg *packages.Package) (*packageEntry, bool) {
+	if entry, ok := c.entries[pkg.ID]; ok {
+		return entry, entry != nil
+	}
+	key, ok := c.packageKeys[pkg.ID]
+	if !ok {
+		return nil, false
+	}
+	entry := &packageEntry{}
+	if !c.load("packages", key, entry) {
+		entry = nil
+	}
+	c.entries[pkg.ID] = entry
+	return entry, entry != nil
+}
+
+// simplifiedASTs returns the cached simplified ASTs of the packages of pkgs
+// that did not change, keyed by file path. The files of a package are the
+// same in its plain and test variants, so either variant's entry will do.
+func (c *extractionCache) simplifiedASTs(pkgs []*packages.Package) map[string]*SimplifiedASTNode {
+	asts := make(map[string]*SimplifiedASTNode)
+	for _, pkg := range pkgs {
+		entry, ok := c.entry(pkg)
+		if !ok {
+			if project, ok := c.byPath[pkg.PkgPath]; ok {
+				entry, ok = c.entry(project)
+			}
+		}
+		if entry != nil {
+			for path, root := range entry.Files {
+				asts[path] = root
+			}
+		}
+	}
+	return asts
+}
+
+// packageGraphs returns the cached graphs of the project packages that did
+// not change, by package ID.
+func (c *extractionCache) packageGraphs() map[string]*packageGraph {
+	graphs := make(map[string]*packageGraph)
+	for _, pkg := range c.pkgs {
+		if entry, ok := c.entry(pkg); ok && entry.Graph != nil {
+			graphs[pkg.ID] = entry.Graph
+		}
+	}
+	return graphs
+}
+
+// changedPackages returns the project packages without a graph in graphs.
+func (c *extractionCache) changedPackages(graphs map[string]*packageGraph) []*packages.Package {
+	var changed []*packages.Package
+	for _, pkg := range c.pkgs {
+		if _, ok := graphs[pkg.ID]; !ok {
+			changed = append(changed, pkg)
+		}
+	}
+	return changed
+}
+
+// storePackages caches the simplified ASTs and, when given, the graphs of
+// the packages of pkgs that were not cached yet.
+func (c *extractionCache) storePackages(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	asts map[string]*SimplifiedASTNode,
+	graphs map[string]*packageGraph,
+) error {
+	for _, pkg := range pkgs {
+		key, ok := c.packageKeys[pkg.ID]
+		if !ok {
+			continue
+		}
+		if cached, ok := c.entry(pkg); ok && (cached.Graph != nil || graphs[pkg.ID] == nil) {
+			continue
+		}
+
+		entry := packageEntry{PkgPath: pkg.PkgPath, Files: make(map[string]*SimplifiedASTNode), Graph: graphs[pkg.ID]}
+		for _, f := range pkg.Syntax {
+			path := fset.Position(f.Package).Filename
+			if root, ok := asts[path]; ok {
+				entry.Files[path] = root
+			}
+		}
+		if err := c.store("packages", key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneCache removes the entries of the cache in dir that were last used
+// more than maxAge ago, or all of them when maxAge is zero, and returns how
+// many it removed.
+func PruneCache(dir string, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+
+	for _, kind := range []string{"packages", "graphs"} {
+		err := filepath.WalkDir(filepath.Join(dir, kind), func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if maxAge == 0 || info.ModTime().Before(cutoff) {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				removed++
+			}
+			return nil
+		})
+		if err != nil {
+			return removed, fmt.Errorf("failed to prune %s: %w", dir, err)
+		}
+	}
+
+	// Drop the shard directories left empty
+	for _, kind := range []string{"packages", "graphs"} {
+		shards, _ := os.ReadDir(filepath.Join(dir, kind))
+		for _, shard := range shards {
+			if shard.IsDir() {
+				os.Remove(filepath.Join(dir, kind, shard.Name()))
+			}
+		}
+	}
+	return removed, nil
+}
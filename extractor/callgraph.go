@@ -0,0 +1,158 @@
+package extractor
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Call graph algorithms accepted by GenerateCallGraphEdges.
+const (
+	CallGraphCHA = "cha"
+	CallGraphRTA = "rta"
+	CallGraphVTA = "vta"
+)
+
+// GenerateCallGraphEdges builds SSA for pkgs and emits "invokes" edges from the
+// call graph computed by algorithm (CHA, RTA or VTA). Unlike
+// GenerateInvokesEdges it also resolves calls through interface values and
+// function values. Each edge is tagged with dispatch=static|dynamic and the
+// algorithm used. Pairs already present in existing are skipped.
+func GenerateCallGraphEdges(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+	algorithm string,
+	existing []GraphEdge,
+) ([]GraphEdge, error) {
+	// Only project packages get function bodies; dependencies are created from
+	// their type information, which is all the project's call sites need.
+	prog, ssaPkgs := ssautil.Packages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+
+	var cg *callgraph.Graph
+	switch algorithm {
+	case CallGraphCHA:
+		cg = cha.CallGraph(prog)
+	case CallGraphRTA:
+		cg = rta.Analyze(callGraphRoots(ssaPkgs), true).CallGraph
+	case CallGraphVTA:
+		cg = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	default:
+		return nil, fmt.Errorf("unknown call graph algorithm %q (want %s, %s or %s)",
+			algorithm, CallGraphCHA, CallGraphRTA, CallGraphVTA)
+	}
+
+	declarations := operationDeclarations(simplifiedASTs)
+	operationID := func(fn *ssa.Function) string {
+		// Function literals are operations of their own; fn.Pos() is the
+		// position of their func keyword, which keys their symbol
+		if fn.Parent() != nil {
+			id, _ := symbolID(symbols, positionKey(fset, fn.Pos()))
+			return id
+		}
+		obj := fn.Object()
+		if obj == nil || !obj.Pos().IsValid() {
+			return ""
+		}
+		id, _ := symbolID(symbols, declarations[positionKey(fset, obj.Pos())])
+		return id
+	}
+
+	seen := map[string]bool{}
+	for _, edge := range existing {
+		if edge.Data.Label == "invokes" {
+			seen[edge.Data.Source+"->"+edge.Data.Target] = true
+		}
+	}
+
+	var edges []GraphEdge
+	err := callgraph.GraphVisitEdges(cg, func(e *callgraph.Edge) error {
+		if e.Site == nil || e.Caller.Func == nil || e.Callee.Func == nil {
+			return nil
+		}
+		sourceID := operationID(e.Caller.Func)
+		if sourceID == "" {
+			return nil
+		}
+		targetID := operationID(e.Callee.Func)
+		if targetID == "" || seen[sourceID+"->"+targetID] {
+			return nil
+		}
+		seen[sourceID+"->"+targetID] = true
+
+		dispatch := "static"
+		if common := e.Site.Common(); common.IsInvoke() || common.StaticCallee() == nil {
+			dispatch = "dynamic"
+		}
+
+		position := fset.Position(e.Site.Pos())
+		AddEdge(&edges, sourceID, targetID, "invokes", map[string]string{
+			"line":      fmt.Sprintf("%d", position.Line-1),
+			"character": fmt.Sprintf("%d", position.Column-1),
+			"dispatch":  dispatch,
+			"algorithm": algorithm,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+// callGraphRoots returns the entry points RTA starts from: main and init of
+// every main package, or every function of the loaded packages when the
+// project is a library.
+func callGraphRoots(ssaPkgs []*ssa.Package) []*ssa.Function {
+	var roots []*ssa.Function
+	for _, pkg := range ssaPkgs {
+		if pkg == nil || pkg.Pkg.Name() != "main" {
+			continue
+		}
+		for _, name := range []string{"init", "main"} {
+			if fn := pkg.Func(name); fn != nil {
+				roots = append(roots, fn)
+			}
+		}
+	}
+	if len(roots) > 0 {
+		return roots
+	}
+
+	for _, pkg := range ssaPkgs {
+		if pkg == nil {
+			continue
+		}
+		for _, member := range pkg.Members {
+			if fn, ok := member.(*ssa.Function); ok {
+				roots = append(roots, fn)
+			}
+		}
+		for _, member := range pkg.Members {
+			t, ok := member.(*ssa.Type)
+			if !ok {
+				continue
+			}
+			if named, ok := t.Type().(*types.Named); ok && named.TypeParams().Len() == 0 {
+				mset := pkg.Prog.MethodSets.MethodSet(types.NewPointer(named))
+				for i := 0; i < mset.Len(); i++ {
+					if fn := pkg.Prog.MethodValue(mset.At(i)); fn != nil {
+						roots = append(roots, fn)
+					}
+				}
+			}
+		}
+	}
+	return roots
+}
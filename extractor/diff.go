@@ -0,0 +1,343 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PositionProperties are the node and edge properties that only record where
+// an element sits in its file. They change whenever unrelated lines move, so
+// diffs usually ignore them.
+var PositionProperties = []string{"line", "character"}
+
+// DiffOptions controls how DiffGraphs compares two graphs.
+type DiffOptions struct {
+	// IgnoreProperties lists property keys whose changes are not reported.
+	IgnoreProperties []string
+}
+
+// GraphDiff is the difference between two graphs, grouped by ontology label.
+// Nodes are grouped by their first label, edges by their label.
+type GraphDiff struct {
+	Nodes map[string]*NodeDiff `json:"nodes"`
+	Edges map[string]*EdgeDiff `json:"edges"`
+}
+
+type NodeDiff struct {
+	Added   []NodeData   `json:"added,omitempty"`
+	Removed []NodeData   `json:"removed,omitempty"`
+	Changed []NodeChange `json:"changed,omitempty"`
+}
+
+type EdgeDiff struct {
+	Added   []EdgeData   `json:"added,omitempty"`
+	Removed []EdgeData   `json:"removed,omitempty"`
+	Changed []EdgeChange `json:"changed,omitempty"`
+}
+
+// NodeChange describes a node present in both graphs whose labels or
+// properties differ.
+type NodeChange struct {
+	ID         string                    `json:"id"`
+	OldLabels  []string                  `json:"oldLabels,omitempty"`
+	NewLabels  []string                  `json:"newLabels,omitempty"`
+	Properties map[string]PropertyChange `json:"properties,omitempty"`
+}
+
+// EdgeChange describes an edge present in both graphs whose properties differ.
+type EdgeChange struct {
+	ID         string                    `json:"id"`
+	Source     string                    `json:"source"`
+	Target     string                    `json:"target"`
+	Properties map[string]PropertyChange `json:"properties"`
+}
+
+// PropertyChange holds the old and new value of a property. A property missing
+// on one side has an empty value there.
+type PropertyChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// LoadGraph reads a graph previously written as Cytoscape JSON.
+func LoadGraph(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open graph: %w", err)
+	}
+	defer f.Close()
+
+	var graph Graph
+	if err := json.NewDecoder(f).Decode(&graph); err != nil {
+		return nil, fmt.Errorf("failed to decode graph %s: %w", path, err)
+	}
+	return &graph, nil
+}
+
+// DiffGraphs reports the nodes and edges added, removed and changed between
+// oldGraph and newGraph. Nodes are matched by ID and edges by their source,
+// label and target; an edge occurring more often in one graph than in the
+// other is reported as added or removed that many times.
+func DiffGraphs(oldGraph, newGraph *Graph, opts DiffOptions) *GraphDiff {
+	ignored := make(map[string]bool)
+	for _, key := range opts.IgnoreProperties {
+		ignored[key] = true
+	}
+
+	diff := &GraphDiff{
+		Nodes: make(map[string]*NodeDiff),
+		Edges: make(map[string]*EdgeDiff),
+	}
+	nodeGroup := func(node NodeData) *NodeDiff {
+		label := nodeGroupLabel(node)
+		if diff.Nodes[label] == nil {
+			diff.Nodes[label] = &NodeDiff{}
+		}
+		return diff.Nodes[label]
+	}
+	edgeGroup := func(edge EdgeData) *EdgeDiff {
+		if diff.Edges[edge.Label] == nil {
+			diff.Edges[edge.Label] = &EdgeDiff{}
+		}
+		return diff.Edges[edge.Label]
+	}
+
+	oldNodes := make(map[string]NodeData)
+	for _, node := range oldGraph.Elements.Nodes {
+		oldNodes[node.Data.ID] = node.Data
+	}
+	newNodes := make(map[string]bool)
+	for _, node := range newGraph.Elements.Nodes {
+		newNodes[node.Data.ID] = true
+		old, ok := oldNodes[node.Data.ID]
+		if !ok {
+			group := nodeGroup(node.Data)
+			group.Added = append(group.Added, node.Data)
+			continue
+		}
+
+		change := NodeChange{
+			ID:         node.Data.ID,
+			Properties: diffProperties(old.Properties, node.Data.Properties, ignored),
+		}
+		if strings.Join(old.Labels, ",") != strings.Join(node.Data.Labels, ",") {
+			change.OldLabels = old.Labels
+			change.NewLabels = node.Data.Labels
+		}
+		if len(change.Properties) > 0 || change.NewLabels != nil {
+			group := nodeGroup(node.Data)
+			group.Changed = append(group.Changed, change)
+		}
+	}
+	for _, node := range oldGraph.Elements.Nodes {
+		if !newNodes[node.Data.ID] {
+			group := nodeGroup(node.Data)
+			group.Removed = append(group.Removed, node.Data)
+		}
+	}
+
+	// Edges are matched by source, label and target rather than by ID, so
+	// that edges sharing an ID are all accounted for
+	oldEdges := edgesByEndpoints(oldGraph)
+	newEdges := edgesByEndpoints(newGraph)
+	for _, key := range sortedKeys(newEdges) {
+		olds, news := withoutIdentical(oldEdges[key], newEdges[key], ignored)
+		paired := min(len(olds), len(news))
+		for i := range paired {
+			group := edgeGroup(news[i])
+			group.Changed = append(group.Changed, EdgeChange{
+				ID:         news[i].ID,
+				Source:     news[i].Source,
+				Target:     news[i].Target,
+				Properties: diffProperties(olds[i].Properties, news[i].Properties, ignored),
+			})
+		}
+		for _, edge := range news[paired:] {
+			group := edgeGroup(edge)
+			group.Added = append(group.Added, edge)
+		}
+		for _, edge := range olds[paired:] {
+			group := edgeGroup(edge)
+			group.Removed = append(group.Removed, edge)
+		}
+	}
+	for _, key := range sortedKeys(oldEdges) {
+		if _, ok := newEdges[key]; ok {
+			continue
+		}
+		for _, edge := range oldEdges[key] {
+			group := edgeGroup(edge)
+			group.Removed = append(group.Removed, edge)
+		}
+	}
+
+	diff.sort()
+	return diff
+}
+
+// edgesByEndpoints groups the edges of graph by source, label and target, in
+// ID order.
+func edgesByEndpoints(graph *Graph) map[string][]EdgeData {
+	edges := make(map[string][]EdgeData)
+	for _, edge := range graph.Elements.Edges {
+		key := edge.Data.Source + "\x00" + edge.Data.Label + "\x00" + edge.Data.Target
+		edges[key] = append(edges[key], edge.Data)
+	}
+	for _, group := range edges {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+	}
+	return edges
+}
+
+// withoutIdentical drops the pairs of old and new edges that do not differ,
+// returning the edges left on either side.
+func withoutIdentical(olds, news []EdgeData, ignored map[string]bool) ([]EdgeData, []EdgeData) {
+	olds = slices.Clone(olds)
+	var left []EdgeData
+	for _, edge := range news {
+		i := slices.IndexFunc(olds, func(old EdgeData) bool {
+			return diffProperties(old.Properties, edge.Properties, ignored) == nil
+		})
+		if i < 0 {
+			left = append(left, edge)
+			continue
+		}
+		olds = slices.Delete(olds, i, i+1)
+	}
+	return olds, left
+}
+
+// Empty reports whether the two graphs were identical.
+func (d *GraphDiff) Empty() bool {
+	return len(d.Nodes) == 0 && len(d.Edges) == 0
+}
+
+// Summary condenses the diff into one line counting the added, removed and
+// changed nodes and edges, e.g. "nodes +2 -0 ~1, edges +3 -1 ~0".
+func (d *GraphDiff) Summary() string {
+	var nodes, edges [3]int
+	for _, group := range d.Nodes {
+		nodes[0] += len(group.Added)
+		nodes[1] += len(group.Removed)
+		nodes[2] += len(group.Changed)
+	}
+	for _, group := range d.Edges {
+		edges[0] += len(group.Added)
+		edges[1] += len(group.Removed)
+		edges[2] += len(group.Changed)
+	}
+	return fmt.Sprintf("nodes +%d -%d ~%d, edges +%d -%d ~%d",
+		nodes[0], nodes[1], nodes[2], edges[0], edges[1], edges[2])
+}
+
+func (d *GraphDiff) sort() {
+	for _, group := range d.Nodes {
+		sort.Slice(group.Added, func(i, j int) bool { return group.Added[i].ID < group.Added[j].ID })
+		sort.Slice(group.Removed, func(i, j int) bool { return group.Removed[i].ID < group.Removed[j].ID })
+		sort.Slice(group.Changed, func(i, j int) bool { return group.Changed[i].ID < group.Changed[j].ID })
+	}
+	for _, group := range d.Edges {
+		sort.Slice(group.Added, func(i, j int) bool { return group.Added[i].ID < group.Added[j].ID })
+		sort.Slice(group.Removed, func(i, j int) bool { return group.Removed[i].ID < group.Removed[j].ID })
+		sort.Slice(group.Changed, func(i, j int) bool { return group.Changed[i].ID < group.Changed[j].ID })
+	}
+}
+
+// nodeGroupLabel is the label a node is reported under.
+func nodeGroupLabel(node NodeData) string {
+	if len(node.Labels) == 0 {
+		return "Unlabeled"
+	}
+	return node.Labels[0]
+}
+
+func diffProperties(oldProps, newProps map[string]string, ignored map[string]bool) map[string]PropertyChange {
+	changes := make(map[string]PropertyChange)
+	for key, value := range newProps {
+		if !ignored[key] && oldProps[key] != value {
+			changes[key] = PropertyChange{Old: oldProps[key], New: value}
+		}
+	}
+	for key, value := range oldProps {
+		if _, ok := newProps[key]; !ok && !ignored[key] {
+			changes[key] = PropertyChange{Old: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// WriteDiffText writes a human-readable report of diff, one section per label.
+func WriteDiffText(w io.Writer, diff *GraphDiff) error {
+	if diff.Empty() {
+		_, err := fmt.Fprintln(w, "No differences.")
+		return err
+	}
+
+	var b strings.Builder
+	if len(diff.Nodes) > 0 {
+		b.WriteString("Nodes:\n")
+		for _, label := range sortedKeys(diff.Nodes) {
+			group := diff.Nodes[label]
+			fmt.Fprintf(&b, "  %s: %d added, %d removed, %d changed\n",
+				label, len(group.Added), len(group.Removed), len(group.Changed))
+			for _, node := range group.Added {
+				fmt.Fprintf(&b, "    + %s\n", node.ID)
+			}
+			for _, node := range group.Removed {
+				fmt.Fprintf(&b, "    - %s\n", node.ID)
+			}
+			for _, change := range group.Changed {
+				fmt.Fprintf(&b, "    ~ %s\n", change.ID)
+				if change.NewLabels != nil {
+					fmt.Fprintf(&b, "        labels: %s -> %s\n",
+						strings.Join(change.OldLabels, ","), strings.Join(change.NewLabels, ","))
+				}
+				writePropertyChanges(&b, change.Properties)
+			}
+		}
+	}
+	if len(diff.Edges) > 0 {
+		b.WriteString("Edges:\n")
+		for _, label := range sortedKeys(diff.Edges) {
+			group := diff.Edges[label]
+			fmt.Fprintf(&b, "  %s: %d added, %d removed, %d changed\n",
+				label, len(group.Added), len(group.Removed), len(group.Changed))
+			for _, edge := range group.Added {
+				fmt.Fprintf(&b, "    + %s -> %s\n", edge.Source, edge.Target)
+			}
+			for _, edge := range group.Removed {
+				fmt.Fprintf(&b, "    - %s -> %s\n", edge.Source, edge.Target)
+			}
+			for _, change := range group.Changed {
+				fmt.Fprintf(&b, "    ~ %s -> %s\n", change.Source, change.Target)
+				writePropertyChanges(&b, change.Properties)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writePropertyChanges(b *strings.Builder, props map[string]PropertyChange) {
+	for _, key := range sortedKeys(props) {
+		fmt.Fprintf(b, "        %s: %q -> %q\n", key, props[key].Old, props[key].New)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
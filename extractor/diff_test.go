@@ -0,0 +1,163 @@
+package extractor_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+func testNode(id, label string, props map[string]string) extractor.GraphNode {
+	return extractor.GraphNode{Data: extractor.NodeData{ID: id, Labels: []string{label}, Properties: props}}
+}
+
+func testEdge(source, target, label string, props map[string]string) extractor.GraphEdge {
+	return extractor.GraphEdge{Data: extractor.EdgeData{
+		ID: source + "->" + target + "." + label, Label: label, Source: source, Target: target, Properties: props,
+	}}
+}
+
+func TestDiffGraphs(t *testing.T) {
+	oldGraph := &extractor.Graph{Elements: extractor.Elements{
+		Nodes: []extractor.GraphNode{
+			testNode("m.A", "Operation", map[string]string{"kind": "func", "line": "2"}),
+			testNode("m.B", "Operation", map[string]string{"kind": "func", "line": "4"}),
+			testNode("m.T", "Type", map[string]string{"kind": "struct"}),
+		},
+		Edges: []extractor.GraphEdge{
+			testEdge("m.A", "m.B", "invokes", map[string]string{"line": "2"}),
+		},
+	}}
+	newGraph := &extractor.Graph{Elements: extractor.Elements{
+		Nodes: []extractor.GraphNode{
+			testNode("m.A", "Operation", map[string]string{"kind": "func", "line": "3"}),
+			testNode("m.C", "Operation", map[string]string{"kind": "func", "line": "6"}),
+			testNode("m.T", "Type", map[string]string{"kind": "interface"}),
+		},
+		Edges: []extractor.GraphEdge{
+			testEdge("m.A", "m.C", "invokes", map[string]string{"line": "3"}),
+		},
+	}}
+
+	diff := extractor.DiffGraphs(oldGraph, newGraph, extractor.DiffOptions{
+		IgnoreProperties: extractor.PositionProperties,
+	})
+
+	ops := diff.Nodes["Operation"]
+	if ops == nil || len(ops.Added) != 1 || ops.Added[0].ID != "m.C" ||
+		len(ops.Removed) != 1 || ops.Removed[0].ID != "m.B" || len(ops.Changed) != 0 {
+		t.Errorf("unexpected Operation diff: %+v", ops)
+	}
+	typeDiff := diff.Nodes["Type"]
+	if typeDiff == nil || len(typeDiff.Changed) != 1 ||
+		typeDiff.Changed[0].Properties["kind"] != (extractor.PropertyChange{Old: "struct", New: "interface"}) {
+		t.Errorf("unexpected Type diff: %+v", typeDiff)
+	}
+	invokes := diff.Edges["invokes"]
+	if invokes == nil || len(invokes.Added) != 1 || invokes.Added[0].Target != "m.C" ||
+		len(invokes.Removed) != 1 || invokes.Removed[0].Target != "m.B" {
+		t.Errorf("unexpected invokes diff: %+v", invokes)
+	}
+
+	var out bytes.Buffer
+	if err := extractor.WriteDiffText(&out, diff); err != nil {
+		t.Fatalf("WriteDiffText failed: %v", err)
+	}
+	for _, want := range []string{"Operation: 1 added, 1 removed, 0 changed", "+ m.A -> m.C", "kind: \"struct\" -> \"interface\""} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("text diff lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	if got := diff.Summary(); got != "nodes +1 -1 ~1, edges +1 -1 ~0" {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	if !extractor.DiffGraphs(oldGraph, oldGraph, extractor.DiffOptions{}).Empty() {
+		t.Error("diff of a graph with itself is not empty")
+	}
+}
+
+func TestDiffGraphsMatchesEdgesByEndpoints(t *testing.T) {
+	declares := testEdge("m/a.go", "m.string", "declares", nil)
+	oldGraph := &extractor.Graph{Elements: extractor.Elements{
+		Edges: []extractor.GraphEdge{
+			declares,
+			testEdge("m.A", "m.B", "invokes", map[string]string{"dispatch": "static"}),
+		},
+	}}
+	// An edge whose ID is taken by another one is still reported
+	newGraph := &extractor.Graph{Elements: extractor.Elements{
+		Edges: []extractor.GraphEdge{
+			declares,
+			declares,
+			testEdge("m.A", "m.B", "invokes", map[string]string{"dispatch": "dynamic"}),
+		},
+	}}
+
+	diff := extractor.DiffGraphs(oldGraph, newGraph, extractor.DiffOptions{})
+	if got := diff.Summary(); got != "nodes +0 -0 ~0, edges +1 -0 ~1" {
+		t.Errorf("Summary() = %q", got)
+	}
+	if invokes := diff.Edges["invokes"]; invokes == nil || len(invokes.Changed) != 1 ||
+		invokes.Changed[0].Properties["dispatch"] != (extractor.PropertyChange{Old: "static", New: "dynamic"}) {
+		t.Errorf("unexpected invokes diff: %+v", invokes)
+	}
+
+	reverse := extractor.DiffGraphs(newGraph, oldGraph, extractor.DiffOptions{})
+	if got := reverse.Summary(); got != "nodes +0 -0 ~0, edges +0 -1 ~1" {
+		t.Errorf("reverse Summary() = %q", got)
+	}
+}
+
+func TestExtractRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := writeModule(t, map[string]string{
+		"go.mod":   "module example.com/rev\n\ngo 1.21\n",
+		"rev/a.go": "package rev\n\nfunc A() int { return 1 }\n",
+	})
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	run("add", "-A")
+	run("commit", "-q", "-m", "first")
+	if err := os.WriteFile(filepath.Join(root, "rev", "a.go"), []byte("package rev\n\nfunc A() int { return B() }\n\nfunc B() int { return 2 }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-q", "-a", "-m", "second")
+
+	ctx := context.Background()
+	oldGraph, err := extractor.ExtractRevision(ctx, root, "HEAD~1", extractor.Options{})
+	if err != nil {
+		t.Fatalf("ExtractRevision(HEAD~1) failed: %v", err)
+	}
+	newGraph, err := extractor.ExtractRevision(ctx, root, "HEAD", extractor.Options{})
+	if err != nil {
+		t.Fatalf("ExtractRevision(HEAD) failed: %v", err)
+	}
+
+	diff := extractor.DiffGraphs(oldGraph, newGraph, extractor.DiffOptions{IgnoreProperties: extractor.PositionProperties})
+	if ops := diff.Nodes["Operation"]; ops == nil || len(ops.Added) != 1 || ops.Added[0].ID != "example.com/rev/rev.B" {
+		t.Errorf("expected example.com/rev/rev.B to be added, got %+v", ops)
+	}
+	if invokes := diff.Edges["invokes"]; invokes == nil || len(invokes.Added) != 1 {
+		t.Errorf("expected one added invokes edge, got %+v", invokes)
+	}
+	if len(diff.Nodes) != 1 {
+		t.Errorf("only Operation nodes should differ, got %v", diff.Nodes)
+	}
+}
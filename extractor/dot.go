@@ -0,0 +1,54 @@
+package extractor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes graph in the Graphviz DOT language. Nodes are labelled by
+// their simpleName and edges by their relationship; labels and properties are
+// kept as additional attributes, which Graphviz ignores when rendering.
+func WriteDOT(w io.Writer, graph *Graph) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("digraph G {\n")
+	for _, node := range graph.Elements.Nodes {
+		label := node.Data.Properties["simpleName"]
+		if label == "" {
+			label = node.Data.ID
+		}
+		attrs := []string{
+			dotAttr("label", label),
+			dotAttr("labels", strings.Join(node.Data.Labels, ";")),
+		}
+		for _, key := range sortedKeys(node.Data.Properties) {
+			if key != "label" && key != "labels" {
+				attrs = append(attrs, dotAttr(key, node.Data.Properties[key]))
+			}
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", dotID(node.Data.ID), strings.Join(attrs, ", "))
+	}
+	for _, edge := range graph.Elements.Edges {
+		attrs := []string{dotAttr("label", edge.Data.Label)}
+		for _, key := range sortedKeys(edge.Data.Properties) {
+			if key != "label" {
+				attrs = append(attrs, dotAttr(key, edge.Data.Properties[key]))
+			}
+		}
+		fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotID(edge.Data.Source), dotID(edge.Data.Target), strings.Join(attrs, ", "))
+	}
+	bw.WriteString("}\n")
+
+	return bw.Flush()
+}
+
+// dotID quotes s as a DOT identifier.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func dotAttr(key, value string) string {
+	return dotID(key) + "=" + dotID(value)
+}
@@ -0,0 +1,808 @@
+package extractor_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+// nodeNames maps node IDs to their simpleName property.
+func nodeNames(graph *extractor.Graph) map[string]string {
+	names := make(map[string]string)
+	for _, node := range graph.Elements.Nodes {
+		names[node.Data.ID] = node.Data.Properties["simpleName"]
+	}
+	return names
+}
+
+// edgesByLabel returns "source -> target" pairs, named by simpleName, of every
+// edge with the given label, along with the edges themselves.
+func edgesByLabel(graph *extractor.Graph, label string) (map[string]extractor.EdgeData, []extractor.EdgeData) {
+	names := nodeNames(graph)
+	pairs := make(map[string]extractor.EdgeData)
+	var edges []extractor.EdgeData
+	for _, edge := range graph.Elements.Edges {
+		if edge.Data.Label != label {
+			continue
+		}
+		pairs[names[edge.Data.Source]+" -> "+names[edge.Data.Target]] = edge.Data
+		edges = append(edges, edge.Data)
+	}
+	return pairs, edges
+}
+
+func TestImplementsEdges(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/store\n\ngo 1.21\n",
+		"store.go": `package store
+
+type Store interface {
+	Get(key string) string
+}
+
+type Closer interface {
+	Close() error
+}
+
+type Empty interface{}
+
+type MemStore struct{}
+
+func (m MemStore) Get(key string) string { return key }
+
+type FileStore struct{}
+
+func (f *FileStore) Get(key string) string { return key }
+
+func (f *FileStore) Close() error { return nil }
+`,
+	}, extractor.Options{})
+
+	pairs, edges := edgesByLabel(graph, "implements")
+
+	want := map[string]string{
+		"MemStore -> Store":   "value",
+		"FileStore -> Store":  "pointer",
+		"FileStore -> Closer": "pointer",
+	}
+	for pair, methodSet := range want {
+		edge, ok := pairs[pair]
+		if !ok {
+			t.Errorf("missing implements edge %s", pair)
+			continue
+		}
+		if edge.Properties["methodSet"] != methodSet {
+			t.Errorf("%s: methodSet = %q, want %q", pair, edge.Properties["methodSet"], methodSet)
+		}
+	}
+	if len(edges) != len(want) {
+		t.Errorf("got %d implements edges, want %d: %v", len(edges), len(want), pairs)
+	}
+}
+
+func TestInvokesEdgesResolveByDeclaration(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/calls\n\ngo 1.21\n",
+		"a/a.go": "package a\n\nfunc Get() int { return 1 }\n",
+		"b/b.go": "package b\n\nfunc Get() int { return 2 }\n",
+		"c/c.go": `package c
+
+import "example.com/calls/b"
+
+type Getter interface {
+	Get() int
+}
+
+func UseB() int { return b.Get() }
+
+func UseGetter(g Getter) int { return g.Get() }
+`,
+	}, extractor.Options{})
+
+	names := nodeNames(graph)
+	_, edges := edgesByLabel(graph, "invokes")
+
+	var fromUseB []string
+	for _, edge := range edges {
+		switch names[edge.Source] {
+		case "UseB":
+			fromUseB = append(fromUseB, edge.Target)
+		case "UseGetter":
+			t.Errorf("interface call must not be linked statically, got edge to %s", edge.Target)
+		}
+	}
+
+	if len(fromUseB) != 1 {
+		t.Fatalf("expected exactly one invokes edge from UseB, got %v", fromUseB)
+	}
+	if got := fromUseB[0]; got != "example.com/calls/b.Get" {
+		t.Errorf("UseB invokes %s, want example.com/calls/b.Get", got)
+	}
+}
+
+func TestCallGraphEdgesResolveDynamicCalls(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/dispatch\n\ngo 1.21\n",
+		"main.go": `package main
+
+type Store interface {
+	Get() int
+}
+
+type MemStore struct{}
+
+func (m *MemStore) Get() int { return 1 }
+
+func read(s Store) int { return s.Get() }
+
+func apply(f func() int) int { return f() }
+
+func one() int { return 1 }
+
+func main() {
+	read(&MemStore{})
+	apply(one)
+}
+`,
+	}
+
+	for _, algorithm := range []string{extractor.CallGraphCHA, extractor.CallGraphRTA, extractor.CallGraphVTA} {
+		t.Run(algorithm, func(t *testing.T) {
+			graph := extractModule(t, files, extractor.Options{CallGraph: algorithm})
+			pairs, _ := edgesByLabel(graph, "invokes")
+
+			for _, pair := range []string{"read -> Get", "apply -> one"} {
+				edge, ok := pairs[pair]
+				if !ok {
+					t.Errorf("missing invokes edge %s", pair)
+					continue
+				}
+				if edge.Properties["dispatch"] != "dynamic" || edge.Properties["algorithm"] != algorithm {
+					t.Errorf("%s: properties = %v", pair, edge.Properties)
+				}
+			}
+			if edge, ok := pairs["main -> read"]; !ok || edge.Properties["dispatch"] != "static" {
+				t.Errorf("expected static invokes edge main -> read, got %v", edge.Properties)
+			}
+		})
+	}
+}
+
+const embeddingModule = `package shapes
+
+type Namer interface {
+	Name() string
+}
+
+type Shape interface {
+	Namer
+	Area() float64
+}
+
+type Base struct {
+	ID int
+}
+
+func (b *Base) Name() string { return "base" }
+
+type Square struct {
+	*Base
+	Side float64
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Labeled struct {
+	Square
+	Shape
+	Label string
+}
+`
+
+func TestEmbedsEdges(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod":    "module example.com/shapes\n\ngo 1.21\n",
+		"shapes.go": embeddingModule,
+	}, extractor.Options{})
+
+	pairs, edges := edgesByLabel(graph, "embeds")
+	want := map[string]string{
+		"Shape -> Namer":    "false",
+		"Square -> Base":    "true",
+		"Labeled -> Square": "false",
+		"Labeled -> Shape":  "false",
+	}
+	for pair, pointer := range want {
+		edge, ok := pairs[pair]
+		if !ok {
+			t.Errorf("missing embeds edge %s", pair)
+			continue
+		}
+		if edge.Properties["pointer"] != pointer {
+			t.Errorf("%s: pointer = %q, want %q", pair, edge.Properties["pointer"], pointer)
+		}
+	}
+	if len(edges) != len(want) {
+		t.Errorf("got %d embeds edges, want %d: %v", len(edges), len(want), pairs)
+	}
+
+	// Embedded fields are not named fields
+	for _, node := range graph.Elements.Nodes {
+		if node.Data.Labels[0] == "Variable" && node.Data.Properties["simpleName"] == "Base" {
+			t.Errorf("embedded Base was recorded as field %s", node.Data.ID)
+		}
+	}
+}
+
+func TestInheritsEdges(t *testing.T) {
+	files := map[string]string{
+		"go.mod":    "module example.com/shapes\n\ngo 1.21\n",
+		"shapes.go": embeddingModule,
+	}
+
+	if pairs, _ := edgesByLabel(extractModule(t, files, extractor.Options{}), "inherits"); len(pairs) != 0 {
+		t.Errorf("inherits edges must be opt-in, got %v", pairs)
+	}
+
+	graph := extractModule(t, files, extractor.Options{Inherits: true})
+	_, edges := edgesByLabel(graph, "inherits")
+
+	got := make(map[string]string)
+	for _, edge := range edges {
+		got[edge.Source+" -> "+edge.Target] = edge.Properties["member"]
+	}
+	want := map[string]string{
+		"example.com/shapes.Shape -> example.com/shapes.Namer.Name":    "method",
+		"example.com/shapes.Square -> example.com/shapes.Base.Name":    "method",
+		"example.com/shapes.Square -> example.com/shapes.Base.ID":      "field",
+		"example.com/shapes.Labeled -> example.com/shapes.Namer.Name":  "method",
+		"example.com/shapes.Labeled -> example.com/shapes.Square.Side": "field",
+		"example.com/shapes.Labeled -> example.com/shapes.Base.ID":     "field",
+	}
+	for pair, member := range want {
+		if got[pair] != member {
+			t.Errorf("inherits %s: member = %q, want %q", pair, got[pair], member)
+		}
+	}
+	// Area is ambiguous in Labeled (Square.Area and Shape.Area at the same
+	// depth) and Base.Name is shadowed by the shallower Namer.Name
+	for _, pair := range []string{
+		"example.com/shapes.Labeled -> example.com/shapes.Square.Area",
+		"example.com/shapes.Labeled -> example.com/shapes.Base.Name",
+	} {
+		if _, ok := got[pair]; ok {
+			t.Errorf("unexpected inherits edge %s", pair)
+		}
+	}
+}
+
+func TestGenericsEdges(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/coll\n\ngo 1.21\n",
+		"coll.go": `package coll
+
+type Number interface {
+	~int | ~float64
+}
+
+type List[T any] struct {
+	items []T
+	next  *List[T]
+}
+
+func (l *List[T]) Push(v T) { l.items = append(l.items, v) }
+
+func Sum[N Number](xs []N) N {
+	var total N
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+type Ints struct {
+	list List[int]
+}
+
+func Use() float64 {
+	l := List[string]{}
+	l.Push("a")
+	return Sum([]float64{1, 2}) + float64(Sum[int]([]int{3}))
+}
+`,
+	}, extractor.Options{})
+
+	labels := make(map[string]string)
+	for _, node := range graph.Elements.Nodes {
+		labels[node.Data.ID] = node.Data.Labels[0]
+	}
+	for _, id := range []string{"example.com/coll.List[T]", "example.com/coll.Sum[N]"} {
+		if labels[id] != "TypeParameter" {
+			t.Errorf("%s: label = %q, want TypeParameter", id, labels[id])
+		}
+	}
+
+	parameterizes, _ := edgesByLabel(graph, "parameterizes")
+	for _, pair := range []string{"T -> List", "N -> Sum"} {
+		if _, ok := parameterizes[pair]; !ok {
+			t.Errorf("missing parameterizes edge %s", pair)
+		}
+	}
+
+	constrainedBy, edges := edgesByLabel(graph, "constrainedBy")
+	if edge, ok := constrainedBy["N -> Number"]; !ok || edge.Properties["constraint"] != "Number" {
+		t.Errorf("missing constrainedBy edge N -> Number, got %v", constrainedBy)
+	}
+	if len(edges) != 1 {
+		t.Errorf("any is not a project interface, got %v", constrainedBy)
+	}
+
+	_, edges = edgesByLabel(graph, "instantiates")
+	got := make(map[string]bool)
+	for _, edge := range edges {
+		got[edge.Source+" -> "+edge.Target+" "+edge.Properties["typeArgs"]] = true
+	}
+	want := []string{
+		"example.com/coll.Ints -> example.com/coll.List int",
+		"example.com/coll.Use -> example.com/coll.List string",
+		"example.com/coll.Use -> example.com/coll.Sum float64",
+		"example.com/coll.Use -> example.com/coll.Sum int",
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing instantiates edge %s", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got instantiates edges %v, want %v", got, want)
+	}
+
+	invokes, _ := edgesByLabel(graph, "invokes")
+	if _, ok := invokes["Use -> Sum"]; !ok {
+		t.Errorf("explicitly instantiated call is not linked, invokes = %v", invokes)
+	}
+}
+
+func TestConstantsAndEnums(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/status\n\ngo 1.21\n",
+		"status.go": `package status
+
+type Code int
+
+const (
+	OK Code = iota
+	NotFound
+	_
+	Failed
+)
+
+const Timeout = 30
+
+const Default Code = 7
+
+var current = OK
+
+func Check(c Code) bool {
+	return c == NotFound || c > Default
+}
+
+func Wait() int { return Timeout * 2 }
+`,
+	}, extractor.Options{})
+
+	nodes := make(map[string]extractor.NodeData)
+	for _, node := range graph.Elements.Nodes {
+		nodes[node.Data.Properties["simpleName"]] = node.Data
+	}
+	for name, value := range map[string]string{"OK": "0", "NotFound": "1", "Failed": "3", "Timeout": "30"} {
+		node := nodes[name]
+		if node.Properties["kind"] != "const" || node.Properties["value"] != value {
+			t.Errorf("%s: kind = %q, value = %q, want const %s", name, node.Properties["kind"], node.Properties["value"], value)
+		}
+	}
+	if nodes["current"].Properties["kind"] != "var" {
+		t.Errorf("current: kind = %q, want var", nodes["current"].Properties["kind"])
+	}
+
+	enumerates, edges := edgesByLabel(graph, "enumerates")
+	for _, pair := range []string{"Code -> OK", "Code -> NotFound", "Code -> Failed"} {
+		if _, ok := enumerates[pair]; !ok {
+			t.Errorf("missing enumerates edge %s", pair)
+		}
+	}
+	if len(edges) != 3 {
+		t.Errorf("constants without iota are not enum members, got %v", enumerates)
+	}
+
+	uses, _ := edgesByLabel(graph, "uses")
+	for _, pair := range []string{"Check -> NotFound", "Check -> Default", "Wait -> Timeout"} {
+		if _, ok := uses[pair]; !ok {
+			t.Errorf("missing uses edge %s", pair)
+		}
+	}
+}
+
+func TestTypedEnumKeepsTypeNode(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/enum\n\ngo 1.21\n",
+		"enum.go": `package enum
+
+type Status int
+
+const (
+	Active Status = iota
+	Inactive
+)
+
+const Fallback Status = Inactive
+
+var current Status = Active
+
+var label string
+`,
+	}
+
+	// The node of a type used in a declaration used to be picked at random
+	// among the type and its declarations, so try a few times
+	for range 5 {
+		graph := extractModule(t, files, extractor.Options{})
+
+		labels := make(map[string][]string)
+		for _, node := range graph.Elements.Nodes {
+			labels[node.Data.ID] = node.Data.Labels
+		}
+		if got := labels["example.com/enum.Status"]; !slices.Equal(got, []string{"Type"}) {
+			t.Fatalf("Status has labels %v, want [Type]", got)
+		}
+		if _, ok := labels["example.com/enum.string"]; ok {
+			t.Fatal("the type of label became a node")
+		}
+		for _, name := range []string{"Active", "Inactive", "Fallback", "current", "label"} {
+			if _, ok := labels["example.com/enum."+name]; !ok {
+				t.Errorf("missing node for %s", name)
+			}
+		}
+	}
+}
+
+func TestGlobalVariableAccess(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/state\n\ngo 1.21\n",
+		"state.go": `package state
+
+type Config struct {
+	Name string
+}
+
+var (
+	counter int
+	config  Config
+	cache   = map[string]int{}
+)
+
+func Inc() { counter++ }
+
+func Get() int { return counter }
+
+func Reset() {
+	counter = 0
+	config.Name = ""
+}
+
+func Store(k string) { cache[k] = counter }
+
+func Ptr() *Config { return &config }
+`,
+	}, extractor.Options{})
+
+	got := make(map[string]bool)
+	_, edges := edgesByLabel(graph, "uses")
+	names := nodeNames(graph)
+	for _, edge := range edges {
+		got[names[edge.Source]+" -> "+names[edge.Target]+" "+edge.Properties["access"]] = true
+	}
+	for _, want := range []string{
+		"Inc -> counter write",
+		"Get -> counter read",
+		"Reset -> counter write",
+		"Reset -> config write",
+		"Store -> cache write",
+		"Store -> counter read",
+		"Ptr -> config addressTaken",
+	} {
+		if !got[want] {
+			t.Errorf("missing uses edge %s", want)
+		}
+	}
+	for _, unwanted := range []string{"Get -> counter write", "Store -> cache read", "Inc -> counter read"} {
+		if got[unwanted] {
+			t.Errorf("unexpected uses edge %s", unwanted)
+		}
+	}
+}
+
+func TestConcurrencyEdges(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/pipe\n\ngo 1.21\n",
+		"pipe.go": `package pipe
+
+var results = make(chan int)
+
+type Worker struct {
+	jobs chan int
+	quit chan struct{}
+}
+
+func (w *Worker) run() {
+	for {
+		select {
+		case j := <-w.jobs:
+			results <- process(j)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+func process(j int) int { return j * 2 }
+
+func Start(w *Worker, in chan int) {
+	go w.run()
+	go func() {
+		for j := range in {
+			w.jobs <- j
+		}
+		close(w.jobs)
+		process(0)
+	}()
+}
+
+func Collect() int { return <-results }
+`,
+	}, extractor.Options{})
+
+	want := map[string]map[string]string{
+		"spawns":   {"Start -> run": "", "Start -> Start.func1": "literal"},
+		"sends":    {"run -> results": "", "Start.func1 -> jobs": ""},
+		"receives": {"run -> jobs": "select", "run -> quit": "select", "Start.func1 -> in": "", "Collect -> results": ""},
+		"closes":   {"Start.func1 -> jobs": ""},
+	}
+	for label, pairs := range want {
+		got, edges := edgesByLabel(graph, label)
+		for pair, flag := range pairs {
+			edge, ok := got[pair]
+			if !ok {
+				t.Errorf("missing %s edge %s", label, pair)
+				continue
+			}
+			if flag != "" && edge.Properties[flag] != "true" {
+				t.Errorf("%s edge %s: %s = %q, want true", label, pair, flag, edge.Properties[flag])
+			}
+		}
+		if len(edges) != len(pairs) {
+			t.Errorf("got %d %s edges, want %d: %v", len(edges), label, len(pairs), got)
+		}
+	}
+}
+
+func TestFuncLitEdges(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/closures\n\ngo 1.21\n",
+		"closures.go": `package closures
+
+var total int
+
+func add(n int) { total += n }
+
+func Counter(step int) func() int {
+	count := 0
+	return func() int {
+		count += step
+		add(count)
+		return count
+	}
+}
+
+func Each(xs []int, f func(int)) {
+	for _, x := range xs {
+		f(x)
+	}
+}
+
+func Sum(xs []int) int {
+	sum := 0
+	Each(xs, func(x int) {
+		sum += x
+		defer func() { add(sum) }()
+	})
+	return sum
+}
+`,
+	}, extractor.Options{})
+
+	labels := make(map[string][]string)
+	for _, node := range graph.Elements.Nodes {
+		labels[node.Data.ID] = node.Data.Labels
+	}
+	for _, id := range []string{
+		"example.com/closures.Counter.func1",
+		"example.com/closures.Sum.func1",
+		"example.com/closures.Sum.func1.func1",
+	} {
+		if len(labels[id]) == 0 || labels[id][0] != "Operation" {
+			t.Errorf("%s: labels = %v, want Operation", id, labels[id])
+		}
+	}
+
+	declares, _ := edgesByLabel(graph, "declares")
+	for _, pair := range []string{"Counter -> Counter.func1", "Sum -> Sum.func1", "Sum.func1 -> Sum.func1.func1"} {
+		if _, ok := declares[pair]; !ok {
+			t.Errorf("missing declares edge %s", pair)
+		}
+	}
+
+	captures, edges := edgesByLabel(graph, "captures")
+	want := []string{
+		"Counter.func1 -> count",
+		"Counter.func1 -> step",
+		"Sum.func1 -> sum",
+		"Sum.func1.func1 -> sum",
+	}
+	for _, pair := range want {
+		if _, ok := captures[pair]; !ok {
+			t.Errorf("missing captures edge %s", pair)
+		}
+	}
+	if len(edges) != len(want) {
+		t.Errorf("got %d captures edges, want %d: %v", len(edges), len(want), captures)
+	}
+
+	invokes, _ := edgesByLabel(graph, "invokes")
+	for _, pair := range []string{"Counter.func1 -> add", "Sum.func1.func1 -> add", "Sum -> Each"} {
+		if _, ok := invokes[pair]; !ok {
+			t.Errorf("missing invokes edge %s", pair)
+		}
+	}
+	if _, ok := invokes["Counter -> add"]; ok {
+		t.Error("calls inside a function literal are attributed to the enclosing function")
+	}
+}
+
+func TestTestsEdges(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.21\n",
+		"calc.go": `package calc
+
+func Add(a, b int) int { return a + b }
+
+func Double(a int) int { return Add(a, a) }
+
+func Square(a int) int { return a * a }
+
+func Unused() {}
+`,
+		"calc_test.go": `package calc
+
+import "testing"
+
+func helper(t *testing.T, got, want int) {
+	if got != want {
+		t.Fatal(got)
+	}
+}
+
+func TestDouble(t *testing.T) {
+	t.Run("two", func(t *testing.T) {
+		helper(t, Double(2), 4)
+	})
+}
+
+func BenchmarkSquare(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Square(i)
+	}
+}
+
+func Testable() {}
+`,
+		"example_test.go": `package calc_test
+
+import (
+	"fmt"
+
+	"example.com/calc"
+)
+
+func ExampleAdd() {
+	fmt.Println(calc.Add(1, 2))
+	// Output: 3
+}
+`,
+	}
+
+	graph := extractModule(t, files, extractor.Options{})
+	for _, node := range graph.Elements.Nodes {
+		if node.Data.Properties["simpleName"] == "TestDouble" {
+			t.Error("test functions are extracted without Tests")
+		}
+	}
+
+	graph = extractModule(t, files, extractor.Options{Tests: true, TestDepth: 2})
+
+	labels := make(map[string][]string)
+	nodeFiles := make(map[string]string)
+	for _, node := range graph.Elements.Nodes {
+		labels[node.Data.Properties["simpleName"]] = node.Data.Labels
+		nodeFiles[node.Data.Properties["simpleName"]] = node.Data.Properties["file"]
+	}
+	for name, want := range map[string]string{"TestDouble": "Test", "BenchmarkSquare": "Benchmark", "ExampleAdd": "Example"} {
+		if !slices.Contains(labels[name], want) {
+			t.Errorf("%s: labels = %v, want %s", name, labels[name], want)
+		}
+	}
+	if len(labels["Testable"]) != 2 {
+		t.Errorf("Testable is not a test, got labels %v", labels["Testable"])
+	}
+	if nodeFiles["ExampleAdd"] != "example.com/calc/example_test.go" {
+		t.Errorf("external test file = %q", nodeFiles["ExampleAdd"])
+	}
+
+	pairs, edges := edgesByLabel(graph, "tests")
+	want := map[string]string{
+		"TestDouble -> Double":      "1",
+		"TestDouble -> Add":         "2",
+		"BenchmarkSquare -> Square": "1",
+		"ExampleAdd -> Add":         "1",
+	}
+	for pair, depth := range want {
+		edge, ok := pairs[pair]
+		if !ok {
+			t.Errorf("missing tests edge %s", pair)
+			continue
+		}
+		if edge.Properties["depth"] != depth {
+			t.Errorf("%s: depth = %s, want %s", pair, edge.Properties["depth"], depth)
+		}
+	}
+	if len(edges) != len(want) {
+		t.Errorf("got %d tests edges, want %d: %v", len(edges), len(want), pairs)
+	}
+}
+
+func TestAnonymousStructFields(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.mod": "module example.com/config\n\ngo 1.21\n",
+		"config.go": `package config
+
+type Server struct {
+	Port int
+}
+
+var cfg struct {
+	Debug bool
+}
+
+var hooks interface {
+	Run()
+}
+`,
+	}, extractor.Options{})
+
+	names := nodeNames(graph)
+	for _, id := range []string{"example.com/config.Server.Debug", "example.com/config.Server.Run"} {
+		if _, ok := names[id]; ok {
+			t.Errorf("%s: a member of an anonymous type is attributed to Server", id)
+		}
+	}
+	encapsulates, _ := edgesByLabel(graph, "encapsulates")
+	for pair := range encapsulates {
+		if pair != "Server -> Port" {
+			t.Errorf("unexpected encapsulates edge %s", pair)
+		}
+	}
+	if _, ok := encapsulates["Server -> Port"]; !ok {
+		t.Errorf("missing encapsulates edge Server -> Port, got %v", encapsulates)
+	}
+}
@@ -0,0 +1,132 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Exporter writes a graph in one output format.
+type Exporter interface {
+	Export(w io.Writer, graph *Graph) error
+}
+
+// ExporterFunc adapts an ordinary function to the Exporter interface.
+type ExporterFunc func(w io.Writer, graph *Graph) error
+
+func (f ExporterFunc) Export(w io.Writer, graph *Graph) error {
+	return f(w, graph)
+}
+
+// DirExporter is implemented by exporters whose output is a bundle of files
+// rather than a single stream. Callers should prefer ExportDir when an
+// exporter implements it.
+type DirExporter interface {
+	Exporter
+	ExportDir(dir string, graph *Graph) error
+}
+
+// Format is a registered output format.
+type Format struct {
+	Name string
+
+	// Extension is the file extension, including the dot, used for files
+	// written in this format. It is empty for DirExporter formats.
+	Extension string
+
+	Exporter Exporter
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// RegisterExporter makes an output format available under name. It panics if
+// the name is registered twice, so it is meant to be called from init.
+func RegisterExporter(name, extension string, exporter Exporter) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if exporter == nil {
+		panic("extractor: RegisterExporter exporter is nil")
+	}
+	if _, dup := formats[name]; dup {
+		panic("extractor: RegisterExporter called twice for format " + name)
+	}
+	formats[name] = Format{Name: name, Extension: extension, Exporter: exporter}
+}
+
+// LookupFormat returns the output format registered under name.
+func LookupFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	format, ok := formats[name]
+	return format, ok
+}
+
+// FormatNames returns the names of all registered output formats, sorted.
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	return sortedKeys(formats)
+}
+
+func init() {
+	RegisterExporter("json", ".json", ExporterFunc(WriteJSON))
+	RegisterExporter("jsonl", ".jsonl", ExporterFunc(WriteJSONLines))
+	RegisterExporter("dot", ".dot", ExporterFunc(WriteDOT))
+	RegisterExporter("cypher", ".cypher", ExporterFunc(WriteCypher))
+	RegisterExporter("graphml", ".graphml", ExporterFunc(WriteGraphML))
+	RegisterExporter("gexf", ".gexf", ExporterFunc(WriteGEXF))
+	RegisterExporter("html", ".html", ExporterFunc(WriteHTML))
+	RegisterExporter("neo4j-csv", "", neo4jCSVExporter{})
+}
+
+// WriteJSON writes graph as indented Cytoscape.js JSON.
+func WriteJSON(w io.Writer, graph *Graph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(graph); err != nil {
+		return fmt.Errorf("failed to encode graph to JSON: %w", err)
+	}
+	return nil
+}
+
+// jsonLine is one line of the JSON Lines output.
+type jsonLine struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// WriteJSONLines writes graph as JSON Lines: one {"type":"node","data":...}
+// object per node followed by one {"type":"edge","data":...} object per edge,
+// so large graphs can be streamed and processed line by line.
+func WriteJSONLines(w io.Writer, graph *Graph) error {
+	encoder := json.NewEncoder(w)
+	for _, node := range graph.Elements.Nodes {
+		if err := encoder.Encode(jsonLine{Type: "node", Data: node.Data}); err != nil {
+			return fmt.Errorf("failed to encode node %s: %w", node.Data.ID, err)
+		}
+	}
+	for _, edge := range graph.Elements.Edges {
+		if err := encoder.Encode(jsonLine{Type: "edge", Data: edge.Data}); err != nil {
+			return fmt.Errorf("failed to encode edge %s: %w", edge.Data.ID, err)
+		}
+	}
+	return nil
+}
+
+// neo4jCSVExporter writes the neo4j-admin CSV bundle, which is a directory.
+type neo4jCSVExporter struct{}
+
+func (neo4jCSVExporter) Export(io.Writer, *Graph) error {
+	return fmt.Errorf("the neo4j-csv format writes a directory and cannot be streamed")
+}
+
+func (neo4jCSVExporter) ExportDir(dir string, graph *Graph) error {
+	return WriteNeo4jCSV(dir, graph)
+}
@@ -0,0 +1,90 @@
+package extractor_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+func TestBuiltinFormatsAreRegistered(t *testing.T) {
+	for _, name := range []string{"json", "jsonl", "dot", "cypher", "graphml", "gexf", "html", "neo4j-csv"} {
+		format, ok := extractor.LookupFormat(name)
+		if !ok {
+			t.Errorf("format %s is not registered", name)
+			continue
+		}
+		if _, isDir := format.Exporter.(extractor.DirExporter); isDir != (format.Extension == "") {
+			t.Errorf("format %s: extension %q does not match DirExporter = %v", name, format.Extension, isDir)
+		}
+	}
+}
+
+func TestRegisterExporter(t *testing.T) {
+	count := extractor.ExporterFunc(func(w io.Writer, graph *extractor.Graph) error {
+		_, err := io.WriteString(w, strings.Repeat("n", len(graph.Elements.Nodes)))
+		return err
+	})
+	extractor.RegisterExporter("test-count", ".txt", count)
+
+	format, ok := extractor.LookupFormat("test-count")
+	if !ok {
+		t.Fatal("registered format not found")
+	}
+	var out bytes.Buffer
+	if err := format.Exporter.Export(&out, exportTestGraph()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if out.String() != "nn" {
+		t.Errorf("Export wrote %q", out.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a format twice did not panic")
+		}
+	}()
+	extractor.RegisterExporter("test-count", ".txt", count)
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	if err := extractor.WriteJSONLines(&out, exportTestGraph()); err != nil {
+		t.Fatalf("WriteJSONLines failed: %v", err)
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var line struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid line %s: %v", scanner.Text(), err)
+		}
+		types = append(types, line.Type)
+	}
+	if got := strings.Join(types, ","); got != "node,node,edge" {
+		t.Errorf("line types = %s", got)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var out bytes.Buffer
+	if err := extractor.WriteDOT(&out, exportTestGraph()); err != nil {
+		t.Fatalf("WriteDOT failed: %v", err)
+	}
+	for _, want := range []string{
+		`"m.Run" ["label"="Run", "labels"="Operation;Type", "doc"="it's", "simpleName"="Run"];`,
+		`"m.Run" -> "m.Stop" ["label"="invokes", "line"="3"];`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("DOT output lacks %s\n%s", want, out.String())
+		}
+	}
+}
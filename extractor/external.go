@@ -0,0 +1,275 @@
+package extractor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// externalNodeID identifies the External node of a package or symbol outside
+// the project, given its qualified name.
+func externalNodeID(qualifiedName string) string {
+	return "external:" + qualifiedName
+}
+
+// GenerateExternalElements returns External nodes for the packages outside
+// the project that its files import, labelled Stdlib or ThirdParty, and for
+// the functions, methods, variables and constants of those packages its
+// operations call or use. Third-party packages carry the module path and
+// version selected by go.mod and, when go.sum lists it, the module's
+// checksum.
+//
+// Files "requires" the packages they import, packages "encloses" their
+// symbols, and operations "invokes" the external functions they call and
+// "uses" the external variables and constants they access.
+func GenerateExternalElements(
+	pkgs []*packages.Package,
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+) ([]GraphNode, []GraphEdge) {
+	var nodes []GraphNode
+	var edges []GraphEdge
+
+	project := make(map[string]bool)
+	for _, pkg := range pkgs {
+		project[pkg.PkgPath] = true
+	}
+	dependencies := make(map[string]*packages.Package)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if !project[pkg.PkgPath] {
+			dependencies[pkg.PkgPath] = pkg
+		}
+	})
+	sums := goSums(pkgs)
+
+	seen := map[string]bool{}
+	packageNode := func(pkgPath string) (string, bool) {
+		dep, ok := dependencies[pkgPath]
+		if !ok || pkgPath == "C" {
+			return "", false
+		}
+		id := externalNodeID(pkgPath)
+		if seen[id] {
+			return id, true
+		}
+		seen[id] = true
+
+		label := "Stdlib"
+		properties := map[string]string{
+			"qualifiedName": pkgPath,
+			"simpleName":    dep.Name,
+		}
+		if dep.Module != nil {
+			label = "ThirdParty"
+			properties["module"] = dep.Module.Path
+			properties["version"] = dep.Module.Version
+			if replace := dep.Module.Replace; replace != nil {
+				properties["replace"] = strings.TrimSpace(replace.Path + " " + replace.Version)
+			}
+			if sum, ok := sums[dep.Module.Path+" "+dep.Module.Version]; ok {
+				properties["sum"] = sum
+			}
+		}
+		nodes = append(nodes, GraphNode{
+			Data: NodeData{
+				ID:         id,
+				Labels:     []string{"External", label},
+				Properties: properties,
+			},
+		})
+		return id, true
+	}
+
+	symbolNode := func(decl *ModifiedDefinitionInfo) (string, bool) {
+		pkgID, ok := packageNode(decl.PackagePath)
+		if !ok {
+			return "", false
+		}
+		qualifiedName := qualify(decl.PackagePath, baseTypeName(decl.ReceiverType), decl.Name)
+		id := externalNodeID(qualifiedName)
+		if seen[id] {
+			return id, true
+		}
+		seen[id] = true
+
+		properties := map[string]string{
+			"qualifiedName": qualifiedName,
+			"simpleName":    decl.Name,
+			"kind":          decl.Kind,
+		}
+		if decl.Value != "" {
+			properties["value"] = decl.Value
+		}
+		nodes = append(nodes, GraphNode{
+			Data: NodeData{
+				ID:         id,
+				Labels:     append([]string{"External"}, KindToLabel(decl.Kind)...),
+				Properties: properties,
+			},
+		})
+		edges = append(edges, GraphEdge{
+			Data: EdgeData{
+				ID:     fmt.Sprintf("encloses:%s->%s", pkgID, id),
+				Label:  "encloses",
+				Source: pkgID,
+				Target: id,
+				Properties: map[string]string{
+					"kind": "ExternalEncloses",
+				},
+			},
+		})
+		return id, true
+	}
+
+	for _, path := range sortedKeys(simplifiedASTs) {
+		root := simplifiedASTs[path]
+		if root == nil || root.Position == nil || shouldIgnorePath(strings.TrimPrefix(root.Position.URI, "file://")) {
+			continue
+		}
+
+		// File "requires" the external packages it imports
+		fileID := rootFileID(root)
+		for _, child := range root.Children {
+			if child.Type != "Import" {
+				continue
+			}
+			importPath := strings.Trim(child.Name, `"`)
+			if project[importPath] {
+				continue
+			}
+			pkgID, ok := packageNode(importPath)
+			if !ok {
+				continue
+			}
+			edges = append(edges, GraphEdge{
+				Data: EdgeData{
+					ID:     fileID + "_requires_" + pkgID,
+					Label:  "requires",
+					Source: fileID,
+					Target: pkgID,
+					Properties: map[string]string{
+						"imported": importPath,
+					},
+				},
+			})
+		}
+
+		// Operations "invokes" and "uses" the external symbols they reference
+		for _, op := range operationNodes(root) {
+			opKey := fmt.Sprintf("%s:%d:%d", op.Position.URI, op.Position.Line, op.Position.Character)
+			opID, ok := symbolID(symbols, opKey)
+			if !ok {
+				continue
+			}
+
+			var refs []*SimplifiedASTNode
+			for _, child := range op.Children {
+				collectExternalReferences(child, project, &refs)
+			}
+
+			linked := map[string]bool{}
+			for _, ref := range refs {
+				targetID, ok := symbolNode(ref.DeclaredAt)
+				if !ok {
+					continue
+				}
+
+				if ref.Type == "Call" || ref.Type == "MethodCall" {
+					if linked[opID+"->"+targetID] {
+						continue
+					}
+					linked[opID+"->"+targetID] = true
+					AddEdge(&edges, opID, targetID, "invokes", map[string]string{
+						"line":      fmt.Sprintf("%d", ref.Position.Line),
+						"character": fmt.Sprintf("%d", ref.Position.Character),
+						"dispatch":  "static",
+					})
+					continue
+				}
+
+				access := ref.Access
+				if access == "" {
+					access = "read"
+				}
+				edgeID := opID + "_uses_" + targetID
+				if access != "read" {
+					edgeID += "." + access
+				}
+				if linked[edgeID] {
+					continue
+				}
+				linked[edgeID] = true
+				edges = append(edges, GraphEdge{
+					Data: EdgeData{
+						ID:     edgeID,
+						Label:  "uses",
+						Source: opID,
+						Target: targetID,
+						Properties: map[string]string{
+							"line":      fmt.Sprintf("%d", ref.Position.Line),
+							"character": fmt.Sprintf("%d", ref.Position.Character),
+							"access":    access,
+						},
+					},
+				})
+			}
+		}
+	}
+
+	return nodes, edges
+}
+
+// collectExternalReferences gathers the calls of functions and methods and
+// the uses of package-level variables and constants below node that are
+// declared outside the project. References inside function literals belong
+// to the literal and are left out.
+func collectExternalReferences(node *SimplifiedASTNode, project map[string]bool, out *[]*SimplifiedASTNode) {
+	if node.Type == "FuncLit" {
+		return
+	}
+	if decl := node.DeclaredAt; decl != nil && decl.PackagePath != "" && !project[decl.PackagePath] && node.Position != nil {
+		switch node.Type {
+		case "Call", "MethodCall":
+			if decl.Kind == "func" {
+				*out = append(*out, node)
+			}
+		case "GlobalVarUse", "ConstUse":
+			*out = append(*out, node)
+		}
+	}
+	for _, child := range node.Children {
+		collectExternalReferences(child, project, out)
+	}
+}
+
+// goSums reads the go.sum files of the project's modules into a map from
+// "path version" to the module's checksum.
+func goSums(pkgs []*packages.Package) map[string]string {
+	sums := make(map[string]string)
+	read := map[string]bool{}
+	for _, pkg := range pkgs {
+		if pkg.Module == nil || !pkg.Module.Main || read[pkg.Module.Dir] {
+			continue
+		}
+		read[pkg.Module.Dir] = true
+
+		f, err := os.Open(filepath.Join(pkg.Module.Dir, "go.sum"))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			// go.mod-only lines have a version ending in /go.mod
+			if len(fields) == 3 && !strings.HasSuffix(fields[1], "/go.mod") {
+				sums[fields[0]+" "+fields[1]] = fields[2]
+			}
+		}
+		f.Close()
+	}
+	return sums
+}
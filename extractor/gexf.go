@@ -0,0 +1,107 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// WriteGEXF writes graph as GEXF 1.3 for Gephi. Nodes are shown by their
+// simpleName and carry their labels, joined with ";", in the "labels"
+// attribute. Edges are labelled with their relationship and every property key
+// is declared as a string attribute of nodes or edges.
+func WriteGEXF(w io.Writer, graph *Graph) error {
+	nodeKeys, edgeKeys := propertyKeys(graph)
+
+	nodeAttributes := gexfAttributes{Class: "node"}
+	nodeAttributes.Attributes = append(nodeAttributes.Attributes, gexfAttribute{ID: "labels", Title: "labels", Type: "string"})
+	nodeAttrIDs := make(map[string]string)
+	for i, key := range nodeKeys {
+		nodeAttrIDs[key] = strconv.Itoa(i)
+		nodeAttributes.Attributes = append(nodeAttributes.Attributes, gexfAttribute{ID: nodeAttrIDs[key], Title: key, Type: "string"})
+	}
+	edgeAttributes := gexfAttributes{Class: "edge"}
+	edgeAttrIDs := make(map[string]string)
+	for i, key := range edgeKeys {
+		edgeAttrIDs[key] = strconv.Itoa(i)
+		edgeAttributes.Attributes = append(edgeAttributes.Attributes, gexfAttribute{ID: edgeAttrIDs[key], Title: key, Type: "string"})
+	}
+
+	doc := gexfDocument{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes:      []gexfAttributes{nodeAttributes, edgeAttributes},
+		},
+	}
+
+	for _, node := range graph.Elements.Nodes {
+		label := node.Data.Properties["simpleName"]
+		if label == "" {
+			label = node.Data.ID
+		}
+		n := gexfNode{ID: node.Data.ID, Label: label}
+		n.AttValues = append(n.AttValues, gexfAttValue{For: "labels", Value: strings.Join(node.Data.Labels, ";")})
+		for _, key := range sortedKeys(node.Data.Properties) {
+			n.AttValues = append(n.AttValues, gexfAttValue{For: nodeAttrIDs[key], Value: node.Data.Properties[key]})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, n)
+	}
+	for _, edge := range graph.Elements.Edges {
+		e := gexfEdge{ID: edge.Data.ID, Source: edge.Data.Source, Target: edge.Data.Target, Label: edge.Data.Label}
+		for _, key := range sortedKeys(edge.Data.Properties) {
+			e.AttValues = append(e.AttValues, gexfAttValue{For: edgeAttrIDs[key], Value: edge.Data.Properties[key]})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+
+	return writeXML(w, doc)
+}
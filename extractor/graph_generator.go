@@ -1,15 +1,22 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
-	"os"
+	"go/token"
+	"go/types"
+	"log"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"golang.org/x/tools/go/packages"
 )
 
 type Graph struct {
@@ -53,81 +60,59 @@ func GenerateGraphNodes(
 	nodes := []GraphNode{}
 	seen := map[string]bool{}
 
+	// Folders and files are identified by their path within the module, so
+	// IDs do not depend on where the project lives on disk
+	rootImportPath := importPathOf(sourceRoot)
+
 	// Add Project node
-	projectNodeID := "project:" + toNodeID(sourceRoot)
+	projectNodeID := "project:" + rootImportPath
 	nodes = append(nodes, GraphNode{
 		Data: NodeData{
 			ID:     projectNodeID,
 			Labels: []string{"Project"},
 			Properties: map[string]string{
-				"qualifiedName": filepath.ToSlash(sourceRoot),
+				"qualifiedName": rootImportPath,
 				"simpleName":    filepath.Base(sourceRoot),
 			},
 		},
 	})
 
 	// Walk the file tree to generate folder/file nodes
-	err := filepath.Walk(sourceRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		normalizedPath := filepath.ToSlash(path)
-
-		if info.IsDir() && filepath.Base(path) == "intermediate_representation" {
-			return filepath.SkipDir
-		}
+	layout, err := newProjectLayout(sourceRoot)
+	if err != nil {
+		return nil, err
+	}
+	treeNodes, _, _, err := layout.projectTree(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range treeNodes {
+		nodes = append(nodes, node)
+		seen[node.Data.ID] = true
+	}
 
-		if info.IsDir() {
-			id := toNodeID(normalizedPath)
-			if !seen[id] {
-				nodes = append(nodes, GraphNode{
-					Data: NodeData{
-						ID:     id,
-						Labels: []string{"Folder"},
-						Properties: map[string]string{
-							"qualifiedName": normalizedPath,
-							"simpleName":    filepath.Base(normalizedPath),
-						},
-					},
-				})
-				seen[id] = true
-			}
-			return nil
+	// Add a Module node per go.mod of the project
+	for _, mod := range layout.modules {
+		properties := map[string]string{
+			"qualifiedName": mod.Path,
+			"simpleName":    path.Base(mod.Path),
 		}
-
-		if filepath.Ext(path) == ".go" {
-			id := toNodeID(normalizedPath + ".go")
-			if !seen[id] {
-				nodes = append(nodes, GraphNode{
-					Data: NodeData{
-						ID:     id,
-						Labels: []string{"File"},
-						Properties: map[string]string{
-							"qualifiedName": normalizedPath,
-							"simpleName":    filepath.Base(normalizedPath),
-						},
-					},
-				})
-				seen[id] = true
-			}
+		if mod.GoVersion != "" {
+			properties["goVersion"] = mod.GoVersion
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		nodes = append(nodes, GraphNode{
+			Data: NodeData{
+				ID:         moduleNodeID(mod.Path),
+				Labels:     []string{"Module"},
+				Properties: properties,
+			},
+		})
 	}
 
 	// Add declaration nodes (functions, types, fields, etc.)
 	for _, def := range symbols {
-		// Only skip local definitions that are not param/field/var
-		if def.Kind == "local" && def.Kind != "param" && def.Kind != "field" && def.Kind != "var" {
-			continue
-		}
-
 		posKey := fmt.Sprintf("%s:%d:%d", def.URI, def.Line, def.Character)
-		id := toNodeID(posKey)
+		id := definitionID(posKey, def)
 
 		if seen[id] {
 			continue
@@ -138,16 +123,29 @@ func GenerateGraphNodes(
 			continue
 		}
 
+		// Positions are properties rather than part of the ID, so that moving
+		// a declaration within its file keeps its identity
 		properties := map[string]string{
 			"simpleName":    def.Name,
-			"qualifiedName": posKey,
+			"qualifiedName": id,
 			"kind":          def.Kind,
+			"file":          fileNodeID(def.PackagePath, def.URI),
+			"line":          fmt.Sprintf("%d", def.Line),
+			"character":     fmt.Sprintf("%d", def.Character),
+		}
+		if def.Value != "" {
+			properties["value"] = def.Value
+		}
+
+		labels := KindToLabel(def.Kind)
+		if label := testFunctionLabel(def); label != "" {
+			labels = append(labels, label)
 		}
 
 		nodes = append(nodes, GraphNode{
 			Data: NodeData{
 				ID:         id,
-				Labels:     KindToLabel(def.Kind),
+				Labels:     labels,
 				Properties: properties,
 			},
 		})
@@ -159,21 +157,20 @@ func GenerateGraphNodes(
 	for _, root := range simplifiedASTs {
 		for _, child := range root.Children {
 			if child.Type == "Package" && child.Name != "" {
-				pkgName := child.Name
-				pkgPath := filepath.ToSlash(strings.TrimPrefix(child.Position.URI, "file://"))
-				dir := filepath.Dir(pkgPath)
-				qualified := fmt.Sprintf("%s/%s", dir, pkgName)
-
-				id := toNodeID(qualified)
+				id := packageNodeID(child)
 				if !addedPackages[id] {
+					properties := map[string]string{
+						"qualifiedName": packagePathOf(child),
+						"simpleName":    child.Name,
+					}
+					if child.DeclaredAt != nil && child.DeclaredAt.PackagePath != "" {
+						properties["importPath"] = child.DeclaredAt.PackagePath
+					}
 					nodes = append(nodes, GraphNode{
 						Data: NodeData{
-							ID:     id + ".package",
-							Labels: []string{"Scope"},
-							Properties: map[string]string{
-								"qualifiedName": qualified + ".package",
-								"simpleName":    pkgName,
-							},
+							ID:         id,
+							Labels:     []string{"Scope"},
+							Properties: properties,
 						},
 					})
 					addedPackages[id] = true
@@ -185,26 +182,94 @@ func GenerateGraphNodes(
 	return nodes, nil
 }
 
+// packagePathOf returns the import path of the package declared by a Package
+// node, falling back to its directory and name for ASTs built without types.
+func packagePathOf(pkgNode *SimplifiedASTNode) string {
+	if pkgNode.DeclaredAt != nil && pkgNode.DeclaredAt.PackagePath != "" {
+		return pkgNode.DeclaredAt.PackagePath
+	}
+	dir := filepath.Dir(filepath.ToSlash(strings.TrimPrefix(pkgNode.Position.URI, "file://")))
+	return toNodeID(fmt.Sprintf("%s/%s", dir, pkgNode.Name))
+}
+
+// packageNodeID identifies the Scope node of the package declared by a
+// Package node.
+func packageNodeID(pkgNode *SimplifiedASTNode) string {
+	return scopeNodeID(packagePathOf(pkgNode))
+}
+
+// rootFileID identifies the File node of a simplified AST root.
+func rootFileID(root *SimplifiedASTNode) string {
+	for _, child := range root.Children {
+		if child.Type == "Package" && child.DeclaredAt != nil {
+			return fileNodeID(child.DeclaredAt.PackagePath, root.Position.URI)
+		}
+	}
+	return fileNodeID("", root.Position.URI)
+}
+
 func KindToLabel(kind string) []string {
     switch kind {
-    case "field", "var", "param":
+    case "field", "var", "param", "local":
         return []string{"Variable"}
     case "func", "method":
         return []string{"Operation", "Type"}
     case "type", "struct", "interface":
         return []string{"Type"}
+    case "typeparam":
+        return []string{"TypeParameter"}
+    case "funclit":
+        return []string{"Operation"}
+    case "const":
+        return []string{"Variable", "Constant"}
     default:
         c := cases.Title(language.English)
         return []string{c.String(kind)}
     }
 }
 
+// testFunctionLabel returns "Test", "Benchmark", "Fuzz" or "Example" for the
+// functions go test runs, and "" for every other declaration. As for go test,
+// the prefix must not be followed by a lower-case letter.
+func testFunctionLabel(def *ModifiedDefinitionInfo) string {
+	if def.Kind != "func" || !strings.HasSuffix(def.URI, "_test.go") || def.Name == "TestMain" {
+		return ""
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		rest, ok := strings.CutPrefix(def.Name, prefix)
+		if !ok {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); rest == "" || !unicode.IsLower(r) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// GenerateInvokesEdges links every operation to the functions and methods it
+// calls. Calls are resolved through the declaration recorded by the type
+// checker, so they land on the exact operation rather than on any operation
+// sharing its name. Calls into the project that cannot be resolved statically
+// (interface methods, function values) are counted and reported in a single
+// line instead of being guessed.
 func GenerateInvokesEdges(
 	simplifiedASTs map[string]*SimplifiedASTNode,
 	symbols map[string]*ModifiedDefinitionInfo,
 ) []GraphEdge {
 	var edges []GraphEdge
 
+	declarations := operationDeclarations(simplifiedASTs)
+	projectFiles := map[string]bool{}
+	for _, root := range simplifiedASTs {
+		if root != nil && root.Position != nil {
+			projectFiles[root.Position.URI] = true
+		}
+	}
+
+	seen := map[string]bool{}
+	unresolved := 0
+
 	// Traverse all simplified ASTs
 	for _, root := range simplifiedASTs {
 		var currentFuncID string
@@ -218,25 +283,57 @@ func GenerateInvokesEdges(
 			switch node.Type {
 			case "Function", "Method":
 				funcKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
-				currentFuncID = toNodeID(funcKey)
+				currentFuncID, _ = symbolID(symbols, funcKey)
 				// Walk children (body, params, etc.) with currentFuncID
 				for _, child := range node.Children {
 					walk(child)
 				}
 				currentFuncID = "" // Clear after done
 
+			case "FuncLit":
+				// Calls inside a function literal are made by the literal
+				enclosingFuncID := currentFuncID
+				funcKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+				currentFuncID, _ = symbolID(symbols, funcKey)
+				for _, child := range node.Children {
+					walk(child)
+				}
+				currentFuncID = enclosingFuncID
+
 			case "Call", "MethodCall":
 				if node.Name == "" || node.Position == nil || currentFuncID == "" {
 					return
 				}
-				// Try to resolve callee name from symbol table
-				for symPosKey, def := range symbols {
-					if def.Name == node.Name && (def.Kind == "func" || def.Kind == "method") {
-						targetID := toNodeID(symPosKey)
-						AddEdge(&edges, currentFuncID, targetID, "invokes", nil)
-						break // Stop after first match
-					}
+
+				decl := node.DeclaredAt
+				if decl != nil && (decl.Kind == "builtin" || decl.Kind == "typename" || decl.Kind == "type") {
+					return // builtin calls and conversions do not invoke an operation
+				}
+				if decl != nil && decl.URI != "" && !projectFiles[decl.URI] {
+					return // declared outside of the project
+				}
+
+				targetKey := ""
+				if decl != nil {
+					targetKey = declarations[fmt.Sprintf("%s:%d:%d", decl.URI, decl.Line, decl.Character)]
 				}
+				def, ok := symbols[targetKey]
+				if !ok || def.Name != node.Name {
+					unresolved++
+					return
+				}
+
+				targetID := definitionID(targetKey, def)
+				if seen[currentFuncID+"->"+targetID] {
+					return
+				}
+				seen[currentFuncID+"->"+targetID] = true
+
+				AddEdge(&edges, currentFuncID, targetID, "invokes", map[string]string{
+					"line":      fmt.Sprintf("%d", node.Position.Line),
+					"character": fmt.Sprintf("%d", node.Position.Character),
+					"dispatch":  "static",
+				})
 
 			default:
 				for _, child := range node.Children {
@@ -248,9 +345,31 @@ func GenerateInvokesEdges(
 		walk(root)
 	}
 
+	if unresolved > 0 {
+		log.Printf("%d calls through interfaces or function values were not linked; use -callgraph to resolve them", unresolved)
+	}
 	return edges
 }
 
+// operationDeclarations maps the position of every function and method name,
+// as recorded in DeclaredAt, to the symbol table key of its declaration.
+func operationDeclarations(simplifiedASTs map[string]*SimplifiedASTNode) map[string]string {
+	declarations := map[string]string{}
+	for _, root := range simplifiedASTs {
+		if root == nil {
+			continue
+		}
+		for _, node := range root.Children {
+			if (node.Type != "Function" && node.Type != "Method") || node.DeclaredAt == nil || node.Position == nil {
+				continue
+			}
+			nameKey := fmt.Sprintf("%s:%d:%d", node.DeclaredAt.URI, node.DeclaredAt.Line, node.DeclaredAt.Character)
+			declarations[nameKey] = fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+		}
+	}
+	return declarations
+}
+
 func GenerateReturnsEdges(
 	simplifiedASTs map[string]*SimplifiedASTNode,
 	symbols map[string]*ModifiedDefinitionInfo,
@@ -262,7 +381,7 @@ func GenerateReturnsEdges(
 	for key, def := range symbols {
 		if def.Kind == "type" || def.Kind == "struct" || def.Kind == "interface" {
 			if !isPrimitiveType(def.Name) {
-				typeNameToID[def.Name] = toNodeID(key)
+				typeNameToID[qualifiedTypeName(def)] = definitionID(key, def)
 			}
 		}
 	}
@@ -270,9 +389,9 @@ func GenerateReturnsEdges(
 	for _, root := range simplifiedASTs {
 		var walk func(node *SimplifiedASTNode)
 		walk = func(node *SimplifiedASTNode) {
-			if (node.Type == "Function" || node.Type == "Method") && node.Position != nil {
+			if (node.Type == "Function" || node.Type == "Method" || node.Type == "FuncLit") && node.Position != nil {
 				sourceKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
-				sourceID := toNodeID(sourceKey)
+				sourceID, _ := symbolID(symbols, sourceKey)
 
 				for _, child := range node.Children {
 					if child.Type == "Results" {
@@ -281,7 +400,7 @@ func GenerateReturnsEdges(
 								// Use declaredAt if available
 								if ret.DeclaredAt != nil {
 									typeName := ret.DeclaredAt.Name
-									if targetID, ok := typeNameToID[typeName]; ok {
+									if targetID, ok := typeNameToID[qualifiedTypeName(ret.DeclaredAt)]; ok {
 										edgeID := fmt.Sprintf("%s->%s.returns", sourceID, targetID)
 										edges = append(edges, GraphEdge{
 											Data: EdgeData{
@@ -322,12 +441,12 @@ func GenerateParameterizesEdges(
 	for _, root := range simplifiedASTs {
 		var walk func(node *SimplifiedASTNode)
 		walk = func(node *SimplifiedASTNode) {
-			if node.Type == "Function" || node.Type == "Method" {
+			if node.Type == "Function" || node.Type == "Method" || node.Type == "FuncLit" {
 				if node.Position == nil {
 					return
 				}
 				funcKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
-				funcID := toNodeID(funcKey)
+				funcID, _ := symbolID(symbols, funcKey)
 
 				for _, child := range node.Children {
 					if child.Type == "Params" {
@@ -336,7 +455,7 @@ func GenerateParameterizesEdges(
 								if ident.Type == "Ident" && ident.Position != nil {
 									paramKey := fmt.Sprintf("%s:%d:%d", ident.Position.URI, ident.Position.Line, ident.Position.Character)
 									if def, ok := symbols[paramKey]; ok && def.Kind == "param" {
-										paramID := toNodeID(paramKey)
+										paramID := definitionID(paramKey, def)
 
 										edges = append(edges, GraphEdge{
 											Data: EdgeData{
@@ -366,6 +485,76 @@ func GenerateParameterizesEdges(
 	return edges
 }
 
+// GenerateTypeParameterEdges links every type parameter to the generic
+// function, method or type it parameterizes, and to the project interface
+// constraining it through a "constrainedBy" edge.
+func GenerateTypeParameterEdges(
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var edges []GraphEdge
+
+	for _, root := range simplifiedASTs {
+		var walk func(node *SimplifiedASTNode, ownerID string)
+		walk = func(node *SimplifiedASTNode, ownerID string) {
+			switch node.Type {
+			case "Function", "Method", "Struct", "Interface", "Type":
+				if node.Name != "" {
+					ownerKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+					ownerID, _ = symbolID(symbols, ownerKey)
+				}
+			case "TypeParam":
+				paramKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+				paramID, ok := symbolID(symbols, paramKey)
+				if !ok || ownerID == "" {
+					break
+				}
+
+				edges = append(edges, GraphEdge{
+					Data: EdgeData{
+						ID:     fmt.Sprintf("%s->%s.parameterizes", paramID, ownerID),
+						Label:  "parameterizes",
+						Source: paramID,
+						Target: ownerID,
+						Properties: map[string]string{
+							"name": node.Name,
+						},
+					},
+				})
+
+				for _, constraint := range node.Children {
+					if constraint.Type != "Constraint" || constraint.DeclaredAt == nil {
+						continue
+					}
+					constraintKey := fmt.Sprintf("%s:%d:%d", constraint.DeclaredAt.URI, constraint.DeclaredAt.Line, constraint.DeclaredAt.Character)
+					constraintID, ok := symbolID(symbols, constraintKey)
+					if !ok {
+						continue
+					}
+					edges = append(edges, GraphEdge{
+						Data: EdgeData{
+							ID:     fmt.Sprintf("%s->%s.constrainedBy", paramID, constraintID),
+							Label:  "constrainedBy",
+							Source: paramID,
+							Target: constraintID,
+							Properties: map[string]string{
+								"constraint": constraint.Name,
+							},
+						},
+					})
+				}
+			}
+
+			for _, child := range node.Children {
+				walk(child, ownerID)
+			}
+		}
+		walk(root, "")
+	}
+
+	return edges
+}
+
 func GenerateTypeEncapsulatesVariableEdges(
 	simplifiedASTs map[string]*SimplifiedASTNode,
 	symbols map[string]*ModifiedDefinitionInfo,
@@ -373,14 +562,22 @@ func GenerateTypeEncapsulatesVariableEdges(
 	var edges []GraphEdge
 
 	for _, astRoot := range simplifiedASTs {
-		var walk func(node *SimplifiedASTNode)
-		walk = func(node *SimplifiedASTNode) {
+		// The fields hang off the unnamed struct type below the named Struct
+		var structID string
+
+		var walk func(node *SimplifiedASTNode, parentType string)
+		walk = func(node *SimplifiedASTNode, parentType string) {
 			if node.Type == "Struct" {
-				structKey := fmt.Sprintf("%s:%d:5", node.Position.URI, node.Position.Line)
-				structID := toNodeID(structKey)
+				if node.Name != "" {
+					structKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+					structID, _ = symbolID(symbols, structKey)
+				} else if parentType != "Struct" {
+					// An anonymous struct type's fields belong to no named type
+					structID = ""
+				}
 
 				for _, field := range node.Children {
-					if field.Type != "Field" {
+					if field.Type != "Field" || structID == "" {
 						continue
 					}
 					for _, ident := range field.Children {
@@ -388,7 +585,10 @@ func GenerateTypeEncapsulatesVariableEdges(
 							continue
 						}
 						fieldKey := fmt.Sprintf("%s:%d:%d", ident.Position.URI, ident.Position.Line, ident.Position.Character)
-						fieldID := toNodeID(fieldKey)
+						fieldID, ok := symbolID(symbols, fieldKey)
+						if !ok {
+							continue
+						}
 
 						edges = append(edges, GraphEdge{
 							Data: EdgeData{
@@ -406,10 +606,64 @@ func GenerateTypeEncapsulatesVariableEdges(
 			}
 
 			for _, child := range node.Children {
-				walk(child)
+				walk(child, node.Type)
+			}
+		}
+		walk(astRoot, "")
+	}
+
+	return edges
+}
+
+// GenerateEmbedsEdges links every named struct or interface to the project
+// types it embeds. The "pointer" property is set for structs embedding *T.
+func GenerateEmbedsEdges(
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var edges []GraphEdge
+
+	for _, astRoot := range simplifiedASTs {
+		var walk func(node *SimplifiedASTNode, typeID string)
+		walk = func(node *SimplifiedASTNode, typeID string) {
+			switch node.Type {
+			case "Struct", "Interface":
+				// The embedded types hang off the unnamed type below the named one
+				if node.Name != "" {
+					typeKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+					typeID, _ = symbolID(symbols, typeKey)
+				}
+			case "Field":
+				// Anonymous structs in field types embed on their own behalf
+				typeID = ""
+			case "Embedded":
+				if typeID == "" || node.DeclaredAt == nil {
+					break
+				}
+				embeddedKey := fmt.Sprintf("%s:%d:%d", node.DeclaredAt.URI, node.DeclaredAt.Line, node.DeclaredAt.Character)
+				embeddedID, ok := symbolID(symbols, embeddedKey)
+				if !ok {
+					break
+				}
+
+				edges = append(edges, GraphEdge{
+					Data: EdgeData{
+						ID:     fmt.Sprintf("%s->%s.embeds", typeID, embeddedID),
+						Label:  "embeds",
+						Source: typeID,
+						Target: embeddedID,
+						Properties: map[string]string{
+							"pointer": fmt.Sprintf("%t", strings.HasPrefix(node.Name, "*")),
+						},
+					},
+				})
+			}
+
+			for _, child := range node.Children {
+				walk(child, typeID)
 			}
 		}
-		walk(astRoot)
+		walk(astRoot, "")
 	}
 
 	return edges
@@ -424,28 +678,25 @@ func GenerateTypedEdges(
 	typeNodeMap := map[string]string{}
 	for key, def := range symbols {
 		if def.Kind == "struct" || def.Kind == "interface" || def.Kind == "type" {
-			qualified := def.Name
-			if def.PackageName != "" {
-				qualified = def.PackageName + "." + def.Name
-			}
-			typeNodeMap[qualified] = toNodeID(key)
+			typeNodeMap[qualifiedTypeName(def)] = definitionID(key, def)
 		}
 	}
 
 	// For every variable/field/param, check if its Type maps to a known type
 	for symKey, def := range symbols {
-		if def.Kind != "param" && def.Kind != "var" && def.Kind != "field" {
+		if def.Kind != "param" && def.Kind != "var" && def.Kind != "field" && def.Kind != "const" && def.Kind != "local" {
 			continue
 		}
 
 		typeName := strings.TrimLeft(def.Type, "*[]") // clean pointer/slice prefix
 
 		if typeID, ok := typeNodeMap[typeName]; ok {
+			sourceID := definitionID(symKey, def)
 			edges = append(edges, GraphEdge{
 				Data: EdgeData{
-					ID:     fmt.Sprintf("%s->%s.typed", toNodeID(symKey), typeID),
+					ID:     fmt.Sprintf("%s->%s.typed", sourceID, typeID),
 					Label:  "typed",
-					Source: toNodeID(symKey),
+					Source: sourceID,
 					Target: typeID,
 					Properties: map[string]string{
 						"type": def.Type, // preserve original form (e.g., "*models.X")
@@ -461,17 +712,23 @@ func GenerateTypedEdges(
 func GenerateTypeEncapsulatesOperationEdges(symbols map[string]*ModifiedDefinitionInfo) []GraphEdge {
 	var edges []GraphEdge
 
-	// Map type name → node ID
+	// Map qualified type name → node ID
 	typeNameToID := make(map[string]string)
-	for id, sym := range symbols {
+	for key, sym := range symbols {
 		if sym.Kind == "struct" || sym.Kind == "interface" {
-			typeNameToID[sym.Name] = id
+			typeNameToID[qualifiedTypeName(sym)] = definitionID(key, sym)
 		}
 	}
 
-	for id, sym := range symbols {
+	for key, sym := range symbols {
 		if sym.Kind == "method" && sym.ReceiverType != "" {
-			if typeID, ok := typeNameToID[sym.ReceiverType]; ok {
+			id := definitionID(key, sym)
+			receiver := &ModifiedDefinitionInfo{
+				Name:        sym.ReceiverType,
+				PackageName: sym.PackageName,
+				PackagePath: sym.PackagePath,
+			}
+			if typeID, ok := typeNameToID[qualifiedTypeName(receiver)]; ok {
 				edgeID := fmt.Sprintf("%s_encapsulates_%s", typeID, id)
 
 				edges = append(edges, GraphEdge{
@@ -495,7 +752,7 @@ func GenerateScopeEnclosesTypeEdges(
 ) []GraphEdge {
 	var edges []GraphEdge
 
-	for _, def := range symbols {
+	for key, def := range symbols {
 		if def.Kind != "struct" && def.Kind != "interface" && def.Kind != "type" {
 			continue
 		}
@@ -504,12 +761,14 @@ func GenerateScopeEnclosesTypeEdges(
 			continue
 		}
 
-		filePath := filepath.ToSlash(def.URI)
-		dir := filepath.Dir(strings.TrimPrefix(filePath, "file://"))
-		scopeQualified := fmt.Sprintf("%s/%s", dir, def.PackageName)
-		scopeID := toNodeID(scopeQualified + ".package")
+		scopePath := def.PackagePath
+		if scopePath == "" {
+			dir := filepath.Dir(strings.TrimPrefix(filepath.ToSlash(def.URI), "file://"))
+			scopePath = toNodeID(fmt.Sprintf("%s/%s", dir, def.PackageName))
+		}
+		scopeID := scopeNodeID(scopePath)
 
-		typeID := toNodeID(fmt.Sprintf("%s:%d:%d", filePath, def.Line, def.Character))
+		typeID := definitionID(key, def)
 
 		edges = append(edges, GraphEdge{
 			Data: EdgeData{
@@ -528,52 +787,12 @@ func GenerateScopeEnclosesTypeEdges(
 }
 
 func GenerateFolderContainsEdges(sourceRoot string) ([]GraphEdge, error) {
-	var edges []GraphEdge
-
-	err := filepath.Walk(sourceRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if path == sourceRoot {
-			// Skip root folder
-			return nil
-		}
-
-		normalizedPath := filepath.ToSlash(path)
-		parent := filepath.Dir(normalizedPath)
-		parentID := toNodeID(filepath.ToSlash(parent))
-		childID := ""
-
-		if info.IsDir() {
-			childID = toNodeID(normalizedPath)
-		} else if filepath.Ext(path) == ".go" {
-			childID = toNodeID(normalizedPath + ".go")
-		} else {
-			return nil // Skip non-Go files
-		}
-
-		edgeID := fmt.Sprintf("%s->%s.contains", parentID, childID)
-		edges = append(edges, GraphEdge{
-			Data: EdgeData{
-				ID:     edgeID,
-				Label:  "contains",
-				Source: parentID,
-				Target: childID,
-				Properties: map[string]string{
-					"kind": "FolderContains",
-				},
-			},
-		})
-
-		return nil
-	})
-
+	layout, err := newProjectLayout(sourceRoot)
 	if err != nil {
 		return nil, err
 	}
-
-	return edges, nil
+	_, edges, _, err := layout.projectTree(false)
+	return edges, err
 }
 
 func GenerateFileDeclaresEdges(symbols map[string]*ModifiedDefinitionInfo) []GraphEdge {
@@ -583,7 +802,7 @@ func GenerateFileDeclaresEdges(symbols map[string]*ModifiedDefinitionInfo) []Gra
 		// Filter by kind
 		if def.Kind != "type" && def.Kind != "struct" && def.Kind != "interface" &&
 			def.Kind != "func" && def.Kind != "method" &&
-			def.Kind != "var" {
+			def.Kind != "var" && def.Kind != "const" {
 			continue
 		}
 
@@ -592,10 +811,8 @@ func GenerateFileDeclaresEdges(symbols map[string]*ModifiedDefinitionInfo) []Gra
 			continue
 		}
 
-		// File ID: file://... (no need to add ".go")
-		trimmed := strings.TrimPrefix(def.URI, "file://")
-		fileID := toNodeID(trimmed + ".go")
-		defID := toNodeID(symKey)
+		fileID := fileNodeID(def.PackagePath, def.URI)
+		defID := definitionID(symKey, def)
 
 		edgeID := fmt.Sprintf("%s->%s.declares", fileID, defID)
 		edges = append(edges, GraphEdge{
@@ -621,13 +838,8 @@ func GenerateFileDeclaresScopeEdges(simplifiedASTs map[string]*SimplifiedASTNode
 	for _, root := range simplifiedASTs {
 		for _, child := range root.Children {
 			if child.Type == "Package" && child.Name != "" && child.Position != nil {
-				trimmed := strings.TrimPrefix(child.Position.URI, "file://")
-				fileID := toNodeID(trimmed + ".go")
-				scopePath := filepath.ToSlash(strings.TrimPrefix(child.Position.URI, "file://"))
-				dir := filepath.Dir(scopePath)
-				qualified := fmt.Sprintf("%s/%s", dir, child.Name)
-
-				scopeID := toNodeID(qualified + ".package")
+				fileID := rootFileID(root)
+				scopeID := packageNodeID(child)
 
 				edgeID := fmt.Sprintf("%s->%s.declares", fileID, scopeID)
 				edges = append(edges, GraphEdge{
@@ -656,39 +868,56 @@ func GenerateOperationUsesVariableEdges(
 	var edges []GraphEdge
 
 	for _, fileNode := range simplifiedASTs {
-		for _, node := range fileNode.Children {
-			if node.Type != "Function" && node.Type != "Method" {
-				continue
-			}
-			if node.DeclaredAt == nil {
+		for _, node := range operationNodes(fileNode) {
+			sourceKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+			operationID, ok := symbolID(symbols, sourceKey)
+			if !ok {
 				continue
 			}
 
-			sourceKey := fmt.Sprintf("%s:%d:%d", node.DeclaredAt.URI, node.DeclaredAt.Line, 0)
-			operationID := toNodeID(sourceKey)
-
 			var uses []*SimplifiedASTNode
-			collectUses(node, &uses)
+			for _, child := range node.Children {
+				collectUses(child, &uses)
+			}
 
+			seen := map[string]bool{}
 			for _, use := range uses {
 				if use.DeclaredAt == nil {
 					continue
 				}
 
-				adjustedLine := use.DeclaredAt.Line - 1
-				adjustedChar := use.DeclaredAt.Character - 1
-				varPosKey := fmt.Sprintf("%s:%d:%d", use.DeclaredAt.URI, adjustedLine, adjustedChar)
-				varID := toNodeID(varPosKey)
+				// Local variables and variables of other modules have no node
+				varPosKey := fmt.Sprintf("%s:%d:%d", use.DeclaredAt.URI, use.DeclaredAt.Line, use.DeclaredAt.Character)
+				varID, ok := symbolID(symbols, varPosKey)
+				if !ok {
+					continue
+				}
+
+				// One edge per way the operation accesses the variable, so
+				// writes stay visible next to reads of the same variable
+				access := use.Access
+				if access == "" {
+					access = "read"
+				}
+				edgeID := operationID + "_uses_" + varID
+				if access != "read" {
+					edgeID += "." + access
+				}
+				if seen[edgeID] {
+					continue
+				}
+				seen[edgeID] = true
 
 				edges = append(edges, GraphEdge{
 					Data: EdgeData{
-						ID:     operationID + "_uses_" + varID,
+						ID:     edgeID,
 						Label:  "uses",
 						Source: operationID,
 						Target: varID,
 						Properties: map[string]string{
 							"line":      fmt.Sprintf("%d", use.Position.Line),
 							"character": fmt.Sprintf("%d", use.Position.Character),
+							"access":    access,
 						},
 					},
 				})
@@ -699,48 +928,242 @@ func GenerateOperationUsesVariableEdges(
 	return edges
 }
 
+// collectUses gathers the variable, field and constant uses below node. Uses
+// inside function literals belong to the literal and are left out.
 func collectUses(node *SimplifiedASTNode, out *[]*SimplifiedASTNode) {
-	if node.Type == "VarUse" || node.Type == "FieldUse" {
+	switch node.Type {
+	case "VarUse", "FieldUse", "GlobalVarUse", "ConstUse":
 		*out = append(*out, node)
+	case "FuncLit":
+		return
 	}
 	for _, child := range node.Children {
 		collectUses(child, out)
 	}
 }
 
-func GenerateRequiresEdges(
+// GenerateConcurrencyEdges links operations to the goroutines they start and
+// to the channels they use: Operation "spawns" Operation for go statements,
+// and Operation "sends", "receives" or "closes" Variable for channel
+// operations. A goroutine running a function literal spawns the literal's
+// Operation. Channel operations inside a select carry select=true.
+func GenerateConcurrencyEdges(
 	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
 ) []GraphEdge {
 	var edges []GraphEdge
 
-	// Map from package name to all file URIs that declare that package
-	packageToFiles := make(map[string][]string)
-
-	for _, fileNode := range simplifiedASTs {
-		if fileNode.Position == nil {
-			continue
+	declarations := operationDeclarations(simplifiedASTs)
+	seen := map[string]bool{}
+	addEdge := func(sourceID, targetID, label string, node *SimplifiedASTNode, properties map[string]string) {
+		id := fmt.Sprintf("%s->%s.%s", sourceID, targetID, label)
+		if seen[id] {
+			return
 		}
-		uri := strings.TrimPrefix(fileNode.Position.URI, "file://")
-		if shouldIgnorePath(uri) {
-			continue
+		seen[id] = true
+		properties["line"] = fmt.Sprintf("%d", node.Position.Line)
+		properties["character"] = fmt.Sprintf("%d", node.Position.Character)
+		edges = append(edges, GraphEdge{
+			Data: EdgeData{
+				ID:         id,
+				Label:      label,
+				Source:     sourceID,
+				Target:     targetID,
+				Properties: properties,
+			},
+		})
+	}
+	operationID := func(decl *ModifiedDefinitionInfo) (string, bool) {
+		if decl == nil {
+			return "", false
 		}
+		return symbolID(symbols, declarations[fmt.Sprintf("%s:%d:%d", decl.URI, decl.Line, decl.Character)])
+	}
+	channelLabels := map[string]string{"Send": "sends", "Receive": "receives", "Close": "closes"}
 
-		for _, child := range fileNode.Children {
-			if child.Type == "Package" {
-				packageToFiles[child.Name] = append(packageToFiles[child.Name], uri)
+	for _, root := range simplifiedASTs {
+		for _, node := range operationNodes(root) {
+			sourceKey := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+			sourceID, ok := symbolID(symbols, sourceKey)
+			if !ok {
+				continue
+			}
+
+			var walk func(n *SimplifiedASTNode, inSelect bool)
+			walk = func(n *SimplifiedASTNode, inSelect bool) {
+				switch n.Type {
+				case "Go":
+					if targetID, ok := operationID(n.DeclaredAt); ok {
+						addEdge(sourceID, targetID, "spawns", n, map[string]string{})
+					}
+					for _, lit := range n.Children {
+						litKey := fmt.Sprintf("%s:%d:%d", lit.Position.URI, lit.Position.Line, lit.Position.Character)
+						if targetID, ok := symbolID(symbols, litKey); ok {
+							addEdge(sourceID, targetID, "spawns", n, map[string]string{"literal": "true"})
+						}
+					}
+					return
+				case "FuncLit":
+					return // operations of their own
+				case "Send", "Receive", "Close":
+					if n.DeclaredAt == nil {
+						return
+					}
+					varKey := fmt.Sprintf("%s:%d:%d", n.DeclaredAt.URI, n.DeclaredAt.Line, n.DeclaredAt.Character)
+					if varID, ok := symbolID(symbols, varKey); ok {
+						properties := map[string]string{}
+						if inSelect {
+							properties["select"] = "true"
+						}
+						addEdge(sourceID, varID, channelLabels[n.Type], n, properties)
+					}
+					return
+				case "Select":
+					inSelect = true
+				}
+				for _, child := range n.Children {
+					walk(child, inSelect)
+				}
+			}
+			for _, child := range node.Children {
+				walk(child, false)
 			}
 		}
 	}
 
-	for _, fileNode := range simplifiedASTs {
-		if fileNode.Position == nil {
-			continue
+	return edges
+}
+
+// operationNodes returns the Function, Method and FuncLit nodes of a
+// simplified AST, enclosing operations before the literals they declare.
+func operationNodes(root *SimplifiedASTNode) []*SimplifiedASTNode {
+	var operations []*SimplifiedASTNode
+	var walk func(node *SimplifiedASTNode)
+	walk = func(node *SimplifiedASTNode) {
+		if node == nil {
+			return
 		}
-		sourceURI := strings.TrimPrefix(fileNode.Position.URI, "file://")
-		if shouldIgnorePath(sourceURI) {
-			continue
+		switch node.Type {
+		case "Function", "Method", "FuncLit":
+			if node.Position != nil {
+				operations = append(operations, node)
+			}
 		}
-
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return operations
+}
+
+// GenerateClosureEdges links every function literal to the operation whose
+// body declares it ("declares") and to the variables of enclosing functions
+// it closes over ("captures").
+func GenerateClosureEdges(
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var edges []GraphEdge
+
+	for _, root := range simplifiedASTs {
+		var walk func(node *SimplifiedASTNode, parentID string)
+		walk = func(node *SimplifiedASTNode, parentID string) {
+			switch node.Type {
+			case "Function", "Method":
+				key := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+				parentID, _ = symbolID(symbols, key)
+
+			case "FuncLit":
+				key := fmt.Sprintf("%s:%d:%d", node.Position.URI, node.Position.Line, node.Position.Character)
+				litID, ok := symbolID(symbols, key)
+				if !ok {
+					return
+				}
+				if parentID != "" {
+					edges = append(edges, GraphEdge{
+						Data: EdgeData{
+							ID:     fmt.Sprintf("%s->%s.declares", parentID, litID),
+							Label:  "declares",
+							Source: parentID,
+							Target: litID,
+							Properties: map[string]string{
+								"kind": "funclit",
+								"name": node.Name,
+							},
+						},
+					})
+				}
+
+				for _, capture := range node.Children {
+					if capture.Type != "Capture" || capture.DeclaredAt == nil {
+						continue
+					}
+					varKey := fmt.Sprintf("%s:%d:%d", capture.DeclaredAt.URI, capture.DeclaredAt.Line, capture.DeclaredAt.Character)
+					varID, ok := symbolID(symbols, varKey)
+					if !ok {
+						continue
+					}
+					edges = append(edges, GraphEdge{
+						Data: EdgeData{
+							ID:     fmt.Sprintf("%s->%s.captures", litID, varID),
+							Label:  "captures",
+							Source: litID,
+							Target: varID,
+							Properties: map[string]string{
+								"line":      fmt.Sprintf("%d", capture.Position.Line),
+								"character": fmt.Sprintf("%d", capture.Position.Character),
+							},
+						},
+					})
+				}
+				parentID = litID
+			}
+
+			for _, child := range node.Children {
+				walk(child, parentID)
+			}
+		}
+		walk(root, "")
+	}
+
+	return edges
+}
+
+func GenerateRequiresEdges(
+	simplifiedASTs map[string]*SimplifiedASTNode,
+) []GraphEdge {
+	var edges []GraphEdge
+
+	// Map from import path to all files that declare that package
+	packageToFiles := make(map[string][]string)
+
+	for _, fileNode := range simplifiedASTs {
+		if fileNode.Position == nil {
+			continue
+		}
+		uri := strings.TrimPrefix(fileNode.Position.URI, "file://")
+		if shouldIgnorePath(uri) {
+			continue
+		}
+
+		for _, child := range fileNode.Children {
+			if child.Type == "Package" {
+				pkgPath := packagePathOf(child)
+				packageToFiles[pkgPath] = append(packageToFiles[pkgPath], rootFileID(fileNode))
+			}
+		}
+	}
+
+	for _, fileNode := range simplifiedASTs {
+		if fileNode.Position == nil {
+			continue
+		}
+		sourceURI := strings.TrimPrefix(fileNode.Position.URI, "file://")
+		if shouldIgnorePath(sourceURI) {
+			continue
+		}
+
 		var importedPkgs []string
 		for _, child := range fileNode.Children {
 			if child.Type == "Import" {
@@ -749,16 +1172,14 @@ func GenerateRequiresEdges(
 			}
 		}
 
+		sourceID := rootFileID(fileNode)
 		for _, pkg := range importedPkgs {
-			targetFiles := packageToFiles[path.Base(pkg)]
-			for _, targetURI := range targetFiles {
-				if targetURI == sourceURI {
+			targetFiles := packageToFiles[pkg]
+			for _, targetID := range targetFiles {
+				if targetID == sourceID {
 					continue
 				}
 
-				sourceID := toNodeID(sourceURI) + ".go"
-				targetID := toNodeID(targetURI) + ".go"
-
 				edges = append(edges, GraphEdge{
 					Data: EdgeData{
 						ID:     sourceID + "_requires_" + targetID,
@@ -778,47 +1199,12 @@ func GenerateRequiresEdges(
 }
 
 func GenerateProjectIncludesEdges(sourceRoot string) ([]GraphEdge, error) {
-	projectNodeID := "project:" + toNodeID(sourceRoot)
-	edges := []GraphEdge{}
-
-	err := filepath.Walk(sourceRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Only include .go files and directories
-		if !info.IsDir() && filepath.Ext(path) != ".go" {
-			return nil
-		}
-
-		targetID := ""
-		if info.IsDir() {
-			targetID = strings.ReplaceAll(toNodeID(path), ".", "/")
-		} else {
-			targetID = strings.ReplaceAll(toNodeID(path), ".", "/") + ".go"
-		}
-
-		edgeID := projectNodeID + "_includes_" + targetID
-
-		edges = append(edges, GraphEdge{
-			Data: EdgeData{
-				ID:     edgeID,
-				Label:  "includes",
-				Source: projectNodeID,
-				Target: targetID,
-				Properties: map[string]string{
-					"type": "includes",
-				},
-			},
-		})
-		return nil
-	})
-
+	layout, err := newProjectLayout(sourceRoot)
 	if err != nil {
 		return nil, err
 	}
-
-	return edges, nil
+	_, _, edges, err := layout.projectTree(false)
+	return edges, err
 }
 
 func GenerateAllEdges(
@@ -826,64 +1212,81 @@ func GenerateAllEdges(
 	symbols map[string]*ModifiedDefinitionInfo,
 	sourceRoot string,
 ) []GraphEdge {
-	var allEdges []GraphEdge
+	edges, _ := runEdgeGenerators(context.Background(), 1, allEdgeGenerators(simplifiedASTs, symbols, sourceRoot))
+	return edges
+}
 
-	folderEdges, err := GenerateFolderContainsEdges(sourceRoot)
-	if err == nil {
-		allEdges = append(allEdges, folderEdges...)
-	}
+// allEdgeGenerators returns the generators of the ontology's edges, in the
+// order GenerateAllEdges concatenates them.
+func allEdgeGenerators(
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+	sourceRoot string,
+) []edgeGenerator {
+	return []edgeGenerator{
+		// Folder "contains" Folders/Files
+		func() []GraphEdge {
+			edges, _ := GenerateFolderContainsEdges(sourceRoot)
+			return edges
+		},
 
-	// File declares Scope
-	scopeDeclEdges := GenerateFileDeclaresScopeEdges(simplifiedASTs)
-	allEdges = append(allEdges, scopeDeclEdges...)
+		// File declares Scope
+		func() []GraphEdge { return GenerateFileDeclaresScopeEdges(simplifiedASTs) },
 
-	// File declares Variable, Type, Operation
-	declaresEdges := GenerateFileDeclaresEdges(symbols)
-	allEdges = append(allEdges, declaresEdges...)
+		// File declares Variable, Type, Operation
+		func() []GraphEdge { return GenerateFileDeclaresEdges(symbols) },
 
-	// Generate "invokes" edges
-	invokesEdges := GenerateInvokesEdges(simplifiedASTs, symbols)
-	allEdges = append(allEdges, invokesEdges...)
+		// Generate "invokes" edges
+		func() []GraphEdge { return GenerateInvokesEdges(simplifiedASTs, symbols) },
 
-	// Generate "returns" edges
-	returnsEdges := GenerateReturnsEdges(simplifiedASTs, symbols)
-	allEdges = append(allEdges, returnsEdges...)
+		// Generate "returns" edges
+		func() []GraphEdge { return GenerateReturnsEdges(simplifiedASTs, symbols) },
 
-	// Generate "parameterizes" edges
-	parameterizesEdges := GenerateParameterizesEdges(simplifiedASTs, symbols)
-	allEdges = append(allEdges, parameterizesEdges...)
+		// Generate "parameterizes" edges
+		func() []GraphEdge { return GenerateParameterizesEdges(simplifiedASTs, symbols) },
 
-	// Generate Type "encapsulates" Variable edges
-	typeEncapsulatesVariableEdges := GenerateTypeEncapsulatesVariableEdges(simplifiedASTs, symbols)
-	allEdges = append(allEdges, typeEncapsulatesVariableEdges...)
-	
-	// Generate Type "encapsulates" Variable edges
-	typeEncapsulatesOperationEdges := GenerateTypeEncapsulatesOperationEdges(symbols)
-	allEdges = append(allEdges, typeEncapsulatesOperationEdges...)
+		// Generate TypeParameter "parameterizes" and "constrainedBy" edges
+		func() []GraphEdge { return GenerateTypeParameterEdges(simplifiedASTs, symbols) },
 
-	// Generate "typed" edges
-	typedEdges := GenerateTypedEdges(symbols)
-	allEdges = append(allEdges, typedEdges...)
+		// Generate Type "encapsulates" Variable edges
+		func() []GraphEdge { return GenerateTypeEncapsulatesVariableEdges(simplifiedASTs, symbols) },
 
-	// Generate Scope "encloses" Type edges
-	scopeEnclosesTypeEdges := GenerateScopeEnclosesTypeEdges(symbols)
-	allEdges = append(allEdges, scopeEnclosesTypeEdges...)
+		// Generate Type "encapsulates" Operation edges
+		func() []GraphEdge { return GenerateTypeEncapsulatesOperationEdges(symbols) },
 
-	// Generate "uses" edges
-	usesEdges := GenerateOperationUsesVariableEdges(simplifiedASTs, symbols)
-	allEdges = append(allEdges, usesEdges...)
+		// Generate Type "embeds" Type edges
+		func() []GraphEdge { return GenerateEmbedsEdges(simplifiedASTs, symbols) },
 
-	// Generate "requires" edges
-	requiresEdges := GenerateRequiresEdges(simplifiedASTs)
-	allEdges = append(allEdges, requiresEdges...)
+		// Generate "typed" edges
+		func() []GraphEdge { return GenerateTypedEdges(symbols) },
 
-	// Generate Project "includes" Files/Folders
-	projectRequiresFilesFoldersEdges, err := GenerateProjectIncludesEdges(sourceRoot)
-	if err == nil {
-		allEdges = append(allEdges, projectRequiresFilesFoldersEdges...)
-	}
+		// Generate Scope "encloses" Type edges
+		func() []GraphEdge { return GenerateScopeEnclosesTypeEdges(symbols) },
+
+		// Generate "uses" edges
+		func() []GraphEdge { return GenerateOperationUsesVariableEdges(simplifiedASTs, symbols) },
 
-	return allEdges
+		// Generate function literal "declares" and "captures" edges
+		func() []GraphEdge { return GenerateClosureEdges(simplifiedASTs, symbols) },
+
+		// Generate "spawns", "sends", "receives" and "closes" edges
+		func() []GraphEdge { return GenerateConcurrencyEdges(simplifiedASTs, symbols) },
+
+		// Generate "requires" edges
+		func() []GraphEdge { return GenerateRequiresEdges(simplifiedASTs) },
+
+		// Generate Project "includes" Files/Folders
+		func() []GraphEdge {
+			edges, _ := GenerateProjectIncludesEdges(sourceRoot)
+			return edges
+		},
+
+		// Generate Module "includes", "contains" and "requires" edges
+		func() []GraphEdge {
+			edges, _ := GenerateModuleEdges(sourceRoot)
+			return edges
+		},
+	}
 }
 
 func AddEdge(edges *[]GraphEdge, fromID, toID, label string, props map[string]string) {
@@ -897,4 +1300,619 @@ func AddEdge(edges *[]GraphEdge, fromID, toID, label string, props map[string]st
 			Properties: props,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// GenerateImplementsEdges connects every named type of the project to every
+// project interface it satisfies. The "methodSet" property tells whether the
+// value method set suffices or the pointer method set is needed. Constraint
+// interfaces, which only restrict type parameters, are left out.
+func GenerateImplementsEdges(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var sets []methodSet
+	for _, pkgSets := range methodSets(fset, pkgs, symbols) {
+		sets = append(sets, pkgSets...)
+	}
+	return implementsEdges(sets)
+}
+
+// methodSet describes a named type of the project as far as implements
+// edges are concerned. Methods are keyed by name, package for unexported
+// names, and signature, so that method sets of packages type-checked in
+// different runs can be compared.
+type methodSet struct {
+	ID        string `json:"id"`
+	Interface bool   `json:"interface,omitempty"`
+	// Value holds the methods of an interface, or the value method set of
+	// any other type
+	Value   []string `json:"value,omitempty"`
+	Pointer []string `json:"pointer,omitempty"`
+}
+
+// methodSets returns, by package ID, the method sets of the non-generic
+// named types of pkgs that have a node, leaving out interfaces without
+// methods, which every type satisfies, and constraint interfaces.
+func methodSets(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	symbols map[string]*ModifiedDefinitionInfo,
+) map[string][]methodSet {
+	sets := make(map[string][]methodSet)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			id, ok := symbolID(symbols, positionKey(fset, typeName.Pos()))
+			if !ok {
+				continue
+			}
+
+			set := methodSet{ID: id}
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				if iface.NumMethods() == 0 || !iface.IsMethodSet() {
+					continue
+				}
+				set.Interface = true
+				for i := range iface.NumMethods() {
+					set.Value = append(set.Value, methodKey(iface.Method(i)))
+				}
+			} else {
+				set.Value = methodKeys(types.NewMethodSet(named))
+				set.Pointer = methodKeys(types.NewMethodSet(types.NewPointer(named)))
+			}
+			slices.Sort(set.Value)
+			sets[pkg.ID] = append(sets[pkg.ID], set)
+		}
+	}
+	return sets
+}
+
+// methodKeys returns the keys of the methods in mset, sorted.
+func methodKeys(mset *types.MethodSet) []string {
+	var keys []string
+	for i := range mset.Len() {
+		if fn, ok := mset.At(i).Obj().(*types.Func); ok {
+			keys = append(keys, methodKey(fn))
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// methodKey identifies a method by its name, qualified by its package when
+// unexported, and by its signature without receiver and parameter names.
+func methodKey(fn *types.Func) string {
+	unnamed := func(tuple *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, tuple.Len())
+		for i := range vars {
+			vars[i] = types.NewParam(token.NoPos, nil, "", tuple.At(i).Type())
+		}
+		return types.NewTuple(vars...)
+	}
+	sig := fn.Type().(*types.Signature)
+	bare := types.NewSignatureType(nil, nil, nil, unnamed(sig.Params()), unnamed(sig.Results()), sig.Variadic())
+	return types.Id(fn.Pkg(), fn.Name()) + " " + types.TypeString(bare, nil)
+}
+
+// implementsEdges links every non-interface type of sets to the interfaces
+// of sets whose methods its value or pointer method set holds.
+func implementsEdges(sets []methodSet) []GraphEdge {
+	var edges []GraphEdge
+
+	var interfaces []methodSet
+	for _, set := range sets {
+		if set.Interface {
+			interfaces = append(interfaces, set)
+		}
+	}
+
+	for _, concrete := range sets {
+		if concrete.Interface {
+			continue
+		}
+		value := make(map[string]bool, len(concrete.Value))
+		for _, key := range concrete.Value {
+			value[key] = true
+		}
+		pointer := make(map[string]bool, len(concrete.Pointer))
+		for _, key := range concrete.Pointer {
+			pointer[key] = true
+		}
+
+		for _, iface := range interfaces {
+			methodSet := ""
+			if hasAll(value, iface.Value) {
+				methodSet = "value"
+			} else if hasAll(pointer, iface.Value) {
+				methodSet = "pointer"
+			} else {
+				continue
+			}
+
+			edges = append(edges, GraphEdge{
+				Data: EdgeData{
+					ID:     fmt.Sprintf("%s->%s.implements", concrete.ID, iface.ID),
+					Label:  "implements",
+					Source: concrete.ID,
+					Target: iface.ID,
+					Properties: map[string]string{
+						"methodSet": methodSet,
+					},
+				},
+			})
+		}
+	}
+
+	return edges
+}
+
+// hasAll reports whether set holds every key of keys.
+func hasAll(set map[string]bool, keys []string) bool {
+	for _, key := range keys {
+		if !set[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateInheritsEdges links every named struct or interface of the project
+// to the methods and fields it gets promoted from the types it embeds, however
+// deeply. The "member" property is "method" or "field".
+func GenerateInheritsEdges(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var edges []GraphEdge
+
+	declarations := operationDeclarations(simplifiedASTs)
+	memberID := func(obj types.Object) (string, bool) {
+		key := positionKey(fset, obj.Pos())
+		if declKey, ok := declarations[key]; ok {
+			key = declKey
+		}
+		return symbolID(symbols, key)
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			sourceID, ok := symbolID(symbols, positionKey(fset, typeName.Pos()))
+			if !ok {
+				continue
+			}
+
+			inherit := func(obj types.Object, member string) {
+				targetID, ok := memberID(obj)
+				if !ok {
+					return
+				}
+				edges = append(edges, GraphEdge{
+					Data: EdgeData{
+						ID:     fmt.Sprintf("%s->%s.inherits", sourceID, targetID),
+						Label:  "inherits",
+						Source: sourceID,
+						Target: targetID,
+						Properties: map[string]string{
+							"member": member,
+						},
+					},
+				})
+			}
+
+			switch underlying := named.Underlying().(type) {
+			case *types.Struct:
+				methods := types.NewMethodSet(types.NewPointer(named))
+				for i := 0; i < methods.Len(); i++ {
+					if sel := methods.At(i); len(sel.Index()) > 1 {
+						inherit(sel.Obj(), "method")
+					}
+				}
+				for _, fieldName := range embeddedFieldNames(underlying) {
+					obj, index, _ := types.LookupFieldOrMethod(named, true, typeName.Pkg(), fieldName)
+					if field, ok := obj.(*types.Var); ok && field.IsField() && len(index) > 1 {
+						inherit(field, "field")
+					}
+				}
+
+			case *types.Interface:
+				explicit := make(map[*types.Func]bool)
+				for i := 0; i < underlying.NumExplicitMethods(); i++ {
+					explicit[underlying.ExplicitMethod(i)] = true
+				}
+				for i := 0; i < underlying.NumMethods(); i++ {
+					if method := underlying.Method(i); !explicit[method] {
+						inherit(method, "method")
+					}
+				}
+			}
+		}
+	}
+
+	return edges
+}
+
+// embeddedFieldNames returns the names of the fields of every struct embedded
+// in st, directly or transitively. Whether each name is actually promoted, or
+// shadowed or ambiguous, is left to types.LookupFieldOrMethod.
+func embeddedFieldNames(st *types.Struct) []string {
+	var names []string
+	seen := make(map[types.Type]bool)
+
+	var collect func(st *types.Struct, embedded bool)
+	collect = func(st *types.Struct, embedded bool) {
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			if embedded {
+				names = append(names, field.Name())
+			}
+			if !field.Embedded() {
+				continue
+			}
+			typ := field.Type()
+			if ptr, ok := typ.(*types.Pointer); ok {
+				typ = ptr.Elem()
+			}
+			if seen[typ] {
+				continue
+			}
+			seen[typ] = true
+			if inner, ok := typ.Underlying().(*types.Struct); ok {
+				collect(inner, true)
+			}
+		}
+	}
+	collect(st, false)
+
+	return names
+}
+
+// GenerateInstantiatesEdges links functions, methods, types and global
+// variables to the generic functions and types they instantiate. Each distinct
+// list of type arguments gets its own edge, recorded in the "typeArgs"
+// property. Instantiations with the declaration's own type parameters, as in
+// a generic type referring to itself, are not instantiations with concrete
+// types and are skipped.
+func GenerateInstantiatesEdges(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var edges []GraphEdge
+	seen := make(map[string]bool)
+
+	declarations := operationDeclarations(simplifiedASTs)
+	declarationID := func(obj types.Object) (string, bool) {
+		key := positionKey(fset, obj.Pos())
+		if declKey, ok := declarations[key]; ok {
+			key = declKey
+		}
+		return symbolID(symbols, key)
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+
+		instantiations := func(sourceID string, node ast.Node) {
+			ast.Inspect(node, func(n ast.Node) bool {
+				ident, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				instance, ok := info.Instances[ident]
+				if !ok || hasTypeParam(instance.TypeArgs) {
+					return true
+				}
+
+				obj := info.Uses[ident]
+				if fn, ok := obj.(*types.Func); ok {
+					obj = fn.Origin()
+				}
+				if obj == nil {
+					return true
+				}
+				targetID, ok := declarationID(obj)
+				if !ok {
+					return true
+				}
+
+				var args []string
+				for i := 0; i < instance.TypeArgs.Len(); i++ {
+					args = append(args, instance.TypeArgs.At(i).String())
+				}
+				typeArgs := strings.Join(args, ", ")
+
+				id := fmt.Sprintf("%s->%s[%s].instantiates", sourceID, targetID, typeArgs)
+				if seen[id] {
+					return true
+				}
+				seen[id] = true
+
+				position := fset.Position(ident.Pos())
+				edges = append(edges, GraphEdge{
+					Data: EdgeData{
+						ID:     id,
+						Label:  "instantiates",
+						Source: sourceID,
+						Target: targetID,
+						Properties: map[string]string{
+							"typeArgs":  typeArgs,
+							"line":      fmt.Sprintf("%d", position.Line-1),
+							"character": fmt.Sprintf("%d", position.Column-1),
+						},
+					},
+				})
+				return true
+			})
+		}
+
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if sourceID, ok := symbolID(symbols, positionKey(fset, d.Pos())); ok {
+						instantiations(sourceID, d)
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						switch sp := spec.(type) {
+						case *ast.TypeSpec:
+							if sourceID, ok := symbolID(symbols, positionKey(fset, sp.Name.Pos())); ok {
+								instantiations(sourceID, sp)
+							}
+						case *ast.ValueSpec:
+							for _, name := range sp.Names {
+								if sourceID, ok := symbolID(symbols, positionKey(fset, name.Pos())); ok {
+									instantiations(sourceID, sp)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return edges
+}
+
+// hasTypeParam reports whether any of the type arguments mentions a type
+// parameter.
+func hasTypeParam(typeArgs *types.TypeList) bool {
+	var mentions func(t types.Type) bool
+	mentions = func(t types.Type) bool {
+		switch t := t.(type) {
+		case *types.TypeParam:
+			return true
+		case *types.Pointer:
+			return mentions(t.Elem())
+		case *types.Slice:
+			return mentions(t.Elem())
+		case *types.Array:
+			return mentions(t.Elem())
+		case *types.Chan:
+			return mentions(t.Elem())
+		case *types.Map:
+			return mentions(t.Key()) || mentions(t.Elem())
+		case *types.Named:
+			args := t.TypeArgs()
+			for i := 0; i < args.Len(); i++ {
+				if mentions(args.At(i)) {
+					return true
+				}
+			}
+		case *types.Signature:
+			for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+				for i := 0; i < tuple.Len(); i++ {
+					if mentions(tuple.At(i).Type()) {
+						return true
+					}
+				}
+			}
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				if mentions(t.Field(i).Type()) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < typeArgs.Len(); i++ {
+		if mentions(typeArgs.At(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateEnumEdges links every named type of the project to the constants of
+// that type declared with iota, the Go idiom for enumerations. The "value"
+// property holds the constant's value.
+func GenerateEnumEdges(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	symbols map[string]*ModifiedDefinitionInfo,
+) []GraphEdge {
+	var edges []GraphEdge
+	iota := types.Universe.Lookup("iota")
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		info := pkg.TypesInfo
+
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+
+				// A spec without values repeats the expressions of the
+				// previous one, so it uses iota whenever that one did
+				usesIota := false
+				for _, spec := range gen.Specs {
+					valueSpec := spec.(*ast.ValueSpec)
+					if len(valueSpec.Values) > 0 {
+						usesIota = false
+						for _, value := range valueSpec.Values {
+							ast.Inspect(value, func(n ast.Node) bool {
+								if ident, ok := n.(*ast.Ident); ok && info.Uses[ident] == iota {
+									usesIota = true
+								}
+								return !usesIota
+							})
+						}
+					}
+					if !usesIota {
+						continue
+					}
+
+					for _, name := range valueSpec.Names {
+						constant, ok := info.Defs[name].(*types.Const)
+						if !ok || name.Name == "_" {
+							continue
+						}
+						named, ok := constant.Type().(*types.Named)
+						if !ok {
+							continue
+						}
+						typeID, ok := symbolID(symbols, positionKey(fset, named.Obj().Pos()))
+						if !ok {
+							continue
+						}
+						constID, ok := symbolID(symbols, positionKey(fset, name.Pos()))
+						if !ok {
+							continue
+						}
+
+						edges = append(edges, GraphEdge{
+							Data: EdgeData{
+								ID:     fmt.Sprintf("%s->%s.enumerates", typeID, constID),
+								Label:  "enumerates",
+								Source: typeID,
+								Target: constID,
+								Properties: map[string]string{
+									"value": constant.Val().ExactString(),
+								},
+							},
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return edges
+}
+
+// GenerateTestsEdges links every Test, Benchmark, Fuzz and Example operation
+// to the production operations it exercises, i.e. those it reaches through at
+// most maxDepth invokes edges. Function literals declared by an operation,
+// such as t.Run subtests, count as part of it. Operations declared in
+// _test.go files are followed but never the target of a tests edge, and
+// neither are External operations. The "depth" property is the number of
+// invokes edges followed.
+func GenerateTestsEdges(nodes []GraphNode, edges []GraphEdge, maxDepth int) []GraphEdge {
+	var result []GraphEdge
+
+	production := map[string]bool{}
+	var tests []string
+	for _, node := range nodes {
+		if !slices.Contains(node.Data.Labels, "Operation") {
+			continue
+		}
+		isTest := false
+		for _, label := range node.Data.Labels {
+			switch label {
+			case "Test", "Benchmark", "Fuzz", "Example":
+				isTest = true
+			}
+		}
+		if isTest {
+			tests = append(tests, node.Data.ID)
+		} else if !strings.HasSuffix(node.Data.Properties["file"], "_test.go") && !slices.Contains(node.Data.Labels, "External") {
+			production[node.Data.ID] = true
+		}
+	}
+
+	invokes := map[string][]string{}
+	literals := map[string][]string{}
+	for _, edge := range edges {
+		switch edge.Data.Label {
+		case "invokes":
+			invokes[edge.Data.Source] = append(invokes[edge.Data.Source], edge.Data.Target)
+		case "declares":
+			if edge.Data.Properties["kind"] == "funclit" {
+				literals[edge.Data.Source] = append(literals[edge.Data.Source], edge.Data.Target)
+			}
+		}
+	}
+	// withLiterals adds id and the function literals it declares, however
+	// deeply nested, to frontier
+	var withLiterals func(id string, frontier []string) []string
+	withLiterals = func(id string, frontier []string) []string {
+		frontier = append(frontier, id)
+		for _, lit := range literals[id] {
+			frontier = withLiterals(lit, frontier)
+		}
+		return frontier
+	}
+
+	for _, test := range tests {
+		visited := map[string]bool{test: true}
+		frontier := withLiterals(test, nil)
+		for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+			var next []string
+			for _, source := range frontier {
+				for _, target := range invokes[source] {
+					if visited[target] {
+						continue
+					}
+					visited[target] = true
+					if production[target] {
+						AddEdge(&result, test, target, "tests", map[string]string{
+							"depth": fmt.Sprintf("%d", depth),
+						})
+					}
+					next = withLiterals(target, next)
+				}
+			}
+			frontier = next
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,127 @@
+package extractor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes graph as GraphML, readable by yEd, Gephi and most graph
+// libraries. A node's labels are joined with ";" into the "labels" attribute
+// and an edge's label becomes the "label" attribute. Every property key is
+// declared as a string attribute of nodes or edges.
+func WriteGraphML(w io.Writer, graph *Graph) error {
+	nodeKeys, edgeKeys := propertyKeys(graph)
+
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: "G", EdgeDefault: "directed"},
+	}
+
+	// Key IDs are generated so that property names never clash with them
+	nodeKeyIDs := map[string]string{"labels": "nl"}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: "nl", For: "node", AttrName: "labels", AttrType: "string"})
+	for i, key := range nodeKeys {
+		nodeKeyIDs[key] = fmt.Sprintf("n%d", i)
+		doc.Keys = append(doc.Keys, graphMLKey{ID: nodeKeyIDs[key], For: "node", AttrName: key, AttrType: "string"})
+	}
+	edgeKeyIDs := map[string]string{"label": "el"}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: "el", For: "edge", AttrName: "label", AttrType: "string"})
+	for i, key := range edgeKeys {
+		edgeKeyIDs[key] = fmt.Sprintf("e%d", i)
+		doc.Keys = append(doc.Keys, graphMLKey{ID: edgeKeyIDs[key], For: "edge", AttrName: key, AttrType: "string"})
+	}
+
+	for _, node := range graph.Elements.Nodes {
+		n := graphMLNode{ID: node.Data.ID}
+		n.Data = append(n.Data, graphMLData{Key: "nl", Value: strings.Join(node.Data.Labels, ";")})
+		for _, key := range sortedKeys(node.Data.Properties) {
+			n.Data = append(n.Data, graphMLData{Key: nodeKeyIDs[key], Value: node.Data.Properties[key]})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, n)
+	}
+	for _, edge := range graph.Elements.Edges {
+		e := graphMLEdge{ID: edge.Data.ID, Source: edge.Data.Source, Target: edge.Data.Target}
+		e.Data = append(e.Data, graphMLData{Key: "el", Value: edge.Data.Label})
+		for _, key := range sortedKeys(edge.Data.Properties) {
+			e.Data = append(e.Data, graphMLData{Key: edgeKeyIDs[key], Value: edge.Data.Properties[key]})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+
+	return writeXML(w, doc)
+}
+
+// propertyKeys returns the sorted property keys used by the nodes and by the
+// edges of graph.
+func propertyKeys(graph *Graph) (nodeKeys, edgeKeys []string) {
+	nodeSet := make(map[string]bool)
+	for _, node := range graph.Elements.Nodes {
+		for key := range node.Data.Properties {
+			nodeSet[key] = true
+		}
+	}
+	edgeSet := make(map[string]bool)
+	for _, edge := range graph.Elements.Edges {
+		for key := range edge.Data.Properties {
+			edgeSet[key] = true
+		}
+	}
+	return sortedKeys(nodeSet), sortedKeys(edgeSet)
+}
+
+// writeXML writes doc as an indented XML document.
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode XML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,104 @@
+package extractor_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+func TestWriteGraphML(t *testing.T) {
+	var out bytes.Buffer
+	if err := extractor.WriteGraphML(&out, exportTestGraph()); err != nil {
+		t.Fatalf("WriteGraphML failed: %v", err)
+	}
+
+	var doc struct {
+		Keys []struct {
+			ID   string `xml:"id,attr"`
+			For  string `xml:"for,attr"`
+			Name string `xml:"attr.name,attr"`
+		} `xml:"key"`
+		Nodes []struct {
+			ID   string `xml:"id,attr"`
+			Data []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"data"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, out.String())
+	}
+
+	names := make(map[string]string)
+	for _, key := range doc.Keys {
+		names[key.ID] = key.For + ":" + key.Name
+	}
+	data := make(map[string]string)
+	for _, d := range doc.Nodes[0].Data {
+		data[names[d.Key]] = d.Value
+	}
+	if data["node:labels"] != "Operation;Type" || data["node:doc"] != "it's" || data["node:simpleName"] != "Run" {
+		t.Errorf("unexpected data of %s: %v", doc.Nodes[0].ID, data)
+	}
+	if len(doc.Edges) != 1 || doc.Edges[0].Source != "m.Run" || doc.Edges[0].Target != "m.Stop" {
+		t.Errorf("unexpected edges: %+v", doc.Edges)
+	}
+}
+
+func TestWriteGEXF(t *testing.T) {
+	var out bytes.Buffer
+	if err := extractor.WriteGEXF(&out, exportTestGraph()); err != nil {
+		t.Fatalf("WriteGEXF failed: %v", err)
+	}
+
+	var doc struct {
+		Attributes []struct {
+			Class      string `xml:"class,attr"`
+			Attributes []struct {
+				ID    string `xml:"id,attr"`
+				Title string `xml:"title,attr"`
+			} `xml:"attribute"`
+		} `xml:"graph>attributes"`
+		Nodes []struct {
+			ID        string `xml:"id,attr"`
+			Label     string `xml:"label,attr"`
+			AttValues []struct {
+				For   string `xml:"for,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"attvalues>attvalue"`
+		} `xml:"graph>nodes>node"`
+		Edges []struct {
+			Label string `xml:"label,attr"`
+		} `xml:"graph>edges>edge"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, out.String())
+	}
+
+	titles := make(map[string]string)
+	for _, attributes := range doc.Attributes {
+		if attributes.Class != "node" {
+			continue
+		}
+		for _, attribute := range attributes.Attributes {
+			titles[attribute.ID] = attribute.Title
+		}
+	}
+	values := make(map[string]string)
+	for _, v := range doc.Nodes[0].AttValues {
+		values[titles[v.For]] = v.Value
+	}
+	if doc.Nodes[0].Label != "Run" || values["labels"] != "Operation;Type" || values["doc"] != "it's" {
+		t.Errorf("unexpected node %s (%s): %v", doc.Nodes[0].ID, doc.Nodes[0].Label, values)
+	}
+	if len(doc.Edges) != 1 || doc.Edges[0].Label != "invokes" {
+		t.Errorf("unexpected edges: %+v", doc.Edges)
+	}
+}
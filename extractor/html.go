@@ -0,0 +1,75 @@
+package extractor
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"slices"
+)
+
+// The viewer is built on Cytoscape.js, whose build is vendored next to it so
+// that the page works offline. It is not fetched at build time; the
+// directive below pins the version checked in.
+//
+//go:generate curl -sSfL -o viewer/cytoscape.min.js https://unpkg.com/cytoscape@3.30.2/dist/cytoscape.min.js
+//go:generate curl -sSfL -o viewer/LICENSE.cytoscape https://unpkg.com/cytoscape@3.30.2/LICENSE
+var (
+	//go:embed viewer
+	viewerFiles embed.FS
+
+	viewerTemplate = template.Must(template.ParseFS(viewerFiles, "viewer/viewer.html"))
+)
+
+// WriteHTML writes graph as a single self-contained HTML page that renders
+// it with Cytoscape.js without network access. Nodes are coloured by their
+// first label, can be searched by simpleName and show their properties when
+// clicked, and Folder, File and Scope nodes collapse into one node standing
+// in for their content.
+func WriteHTML(w io.Writer, graph *Graph) error {
+	library, err := fs.ReadFile(viewerFiles, "viewer/cytoscape.min.js")
+	if errors.Is(err, fs.ErrNotExist) {
+		return errors.New("the HTML viewer needs the vendored Cytoscape.js: run go generate ./extractor and rebuild")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Cytoscape.js: %w", err)
+	}
+	style, err := fs.ReadFile(viewerFiles, "viewer/viewer.css")
+	if err != nil {
+		return fmt.Errorf("failed to read viewer style: %w", err)
+	}
+	script, err := fs.ReadFile(viewerFiles, "viewer/viewer.js")
+	if err != nil {
+		return fmt.Errorf("failed to read viewer script: %w", err)
+	}
+
+	// json.Marshal escapes <, > and &, so the graph cannot end its script
+	// element; Cytoscape.js reads it as it is
+	data, err := json.Marshal(graph)
+	if err != nil {
+		return fmt.Errorf("failed to encode graph to JSON: %w", err)
+	}
+
+	title := "Knowledge graph"
+	for _, node := range graph.Elements.Nodes {
+		if slices.Contains(node.Data.Labels, "Project") {
+			title = node.Data.Properties["simpleName"]
+			break
+		}
+	}
+
+	err = viewerTemplate.Execute(w, struct {
+		Title   string
+		Style   template.CSS
+		Library template.JS
+		Script  template.JS
+		Graph   template.JS
+	}{title, template.CSS(style), template.JS(library), template.JS(script), template.JS(data)})
+	if err != nil {
+		return fmt.Errorf("failed to write HTML viewer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package extractor_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+func TestWriteHTML(t *testing.T) {
+	graph := exportTestGraph()
+	// A property must not be able to end the script element holding the graph
+	graph.Elements.Nodes[1].Data.Properties["doc"] = "</script><script>alert(1)</script>"
+
+	var out bytes.Buffer
+	err := extractor.WriteHTML(&out, graph)
+	if err != nil && strings.Contains(err.Error(), "go generate") {
+		t.Skip("Cytoscape.js is not vendored")
+	}
+	if err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+	page := out.String()
+
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") {
+		t.Errorf("page does not start with a doctype:\n%.200s", page)
+	}
+	if strings.Contains(page, "alert(1)</script>") {
+		t.Error("a property ends the script element holding the graph")
+	}
+	// Everything the page needs is inline, so it opens offline
+	if external := regexp.MustCompile(`(?i)<(script|link|img)[^>]+(src|href)=`).FindString(page); external != "" {
+		t.Errorf("page loads an external resource: %s", external)
+	}
+
+	match := regexp.MustCompile(`(?s)<script type="application/json" id="graph-data">(.*?)</script>`).FindStringSubmatch(page)
+	if match == nil {
+		t.Fatal("page does not embed the graph")
+	}
+	var embedded extractor.Graph
+	if err := json.Unmarshal([]byte(match[1]), &embedded); err != nil {
+		t.Fatalf("embedded graph is not valid JSON: %v", err)
+	}
+	if len(embedded.Elements.Nodes) != 2 || len(embedded.Elements.Edges) != 1 {
+		t.Errorf("embedded graph has %d nodes and %d edges, want 2 and 1",
+			len(embedded.Elements.Nodes), len(embedded.Elements.Edges))
+	}
+	if got := embedded.Elements.Nodes[1].Data.Properties["doc"]; got != graph.Elements.Nodes[1].Data.Properties["doc"] {
+		t.Errorf("embedded property is %q", got)
+	}
+}
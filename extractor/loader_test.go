@@ -0,0 +1,434 @@
+package extractor_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+// writeModule lays out a throwaway module in a temporary directory and
+// returns its root. Keys of files are slash-separated paths relative to it.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatalf("mkdir for %s failed: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+	return root
+}
+
+// extractModule runs the whole pipeline over a throwaway module.
+func extractModule(t *testing.T, files map[string]string, opts extractor.Options) *extractor.Graph {
+	t.Helper()
+
+	graph, err := extractor.Extract(context.Background(), writeModule(t, files), opts)
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	return graph
+}
+
+func TestLoadPackagesKeepsSameNamedPackagesApart(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"go.mod":        "module example.com/multi\n\ngo 1.21\n",
+		"cmd/a/main.go": "package main\n\nfunc helper() int { return 1 }\n\nfunc main() { helper() }\n",
+		"cmd/b/main.go": "package main\n\nfunc helper() string { return \"b\" }\n\nfunc main() { helper() }\n",
+	})
+
+	_, pkgs, err := extractor.LoadPackages(root)
+	if err != nil {
+		t.Fatalf("LoadPackages failed: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			t.Errorf("unexpected errors in %s: %v", pkg.PkgPath, pkg.Errors)
+		}
+		seen[pkg.PkgPath] = true
+	}
+	for _, want := range []string{"example.com/multi/cmd/a", "example.com/multi/cmd/b"} {
+		if !seen[want] {
+			t.Errorf("package %s was not loaded", want)
+		}
+	}
+
+	graph, err := extractor.Extract(context.Background(), root, extractor.Options{})
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	scopes := map[string]bool{}
+	for _, node := range graph.Elements.Nodes {
+		if node.Data.Labels[0] == "Scope" {
+			scopes[node.Data.Properties["importPath"]] = true
+		}
+	}
+	if !scopes["example.com/multi/cmd/a"] || !scopes["example.com/multi/cmd/b"] {
+		t.Errorf("expected one Scope per main package, got %v", scopes)
+	}
+}
+
+func TestBuildContexts(t *testing.T) {
+	files := map[string]string{
+		"go.mod":              "module example.com/plat\n\ngo 1.21\n",
+		"plat.go":             "package plat\n\nfunc Name() string { return name() }\n",
+		"plat_linux.go":       "package plat\n\nfunc name() string { return \"linux\" }\n",
+		"plat_windows.go":     "package plat\n\nfunc name() string { return \"windows\" }\n\nfunc Registry() {}\n",
+		"plat_integration.go": "//go:build integration\n\npackage plat\n\nfunc Fixture() {}\n",
+	}
+
+	// nodeContexts maps the simpleName of every file and operation to its
+	// buildContexts property
+	nodeContexts := func(graph *extractor.Graph) map[string]string {
+		contexts := make(map[string]string)
+		for _, node := range graph.Elements.Nodes {
+			contexts[node.Data.Properties["simpleName"]] = node.Data.Properties["buildContexts"]
+		}
+		return contexts
+	}
+
+	linux := nodeContexts(extractModule(t, files, extractor.Options{
+		BuildContexts: []extractor.BuildContext{{GOOS: "linux", GOARCH: "amd64"}},
+	}))
+	for _, name := range []string{"plat_windows.go", "Registry", "plat_integration.go", "Fixture"} {
+		if _, ok := linux[name]; ok {
+			t.Errorf("%s is excluded on linux/amd64", name)
+		}
+	}
+	if _, ok := linux["plat_linux.go"]; !ok {
+		t.Error("plat_linux.go is missing on linux/amd64")
+	}
+
+	tagged := nodeContexts(extractModule(t, files, extractor.Options{
+		BuildContexts: []extractor.BuildContext{{GOOS: "linux", GOARCH: "amd64", Tags: []string{"integration"}}},
+	}))
+	if _, ok := tagged["Fixture"]; !ok {
+		t.Error("Fixture is missing with the integration tag")
+	}
+
+	merged := nodeContexts(extractModule(t, files, extractor.Options{
+		BuildContexts: []extractor.BuildContext{
+			{GOOS: "linux", GOARCH: "amd64"},
+			{GOOS: "windows", GOARCH: "amd64"},
+		},
+	}))
+	want := map[string]string{
+		"Name":            "linux/amd64;windows/amd64",
+		"name":            "linux/amd64;windows/amd64",
+		"plat_linux.go":   "linux/amd64",
+		"plat_windows.go": "windows/amd64",
+		"Registry":        "windows/amd64",
+	}
+	for name, contexts := range want {
+		if merged[name] != contexts {
+			t.Errorf("%s: buildContexts = %q, want %q", name, merged[name], contexts)
+		}
+	}
+}
+
+func TestWorkspaceModules(t *testing.T) {
+	graph := extractModule(t, map[string]string{
+		"go.work":                 "go 1.21\n\nuse (\n\t./api\n\t./lib\n)\n",
+		"api/go.mod":              "module example.com/api\n\ngo 1.21\n\nrequire example.com/lib v0.1.0\n",
+		"api/api.go":              "package api\n\nimport \"example.com/lib\"\n\nfunc Serve() string { return lib.Hello() }\n",
+		"lib/go.mod":              "module example.com/lib\n\ngo 1.21\n",
+		"lib/lib.go":              "package lib\n\nfunc Hello() string { return \"hello\" }\n",
+		"lib/testdata/fixture.go": "package fixture\n\nfunc Fixture() {}\n",
+		"lib/tools/go.mod":        "module example.com/lib/tools\n\ngo 1.21\n",
+		"lib/tools/tools.go":      "package tools\n\nfunc Tool() {}\n",
+		"lib/vendor/modules.txt":  "",
+		"lib/vendor/x.org/v/v.go": "package v\n",
+	}, extractor.Options{})
+
+	ids := map[string]bool{}
+	for _, node := range graph.Elements.Nodes {
+		ids[node.Data.ID] = true
+	}
+	for _, id := range []string{"module:example.com/api", "module:example.com/lib", "example.com/api/api.go", "example.com/lib/lib.go"} {
+		if !ids[id] {
+			t.Errorf("node %s is missing", id)
+		}
+	}
+	for _, id := range []string{"example.com/lib/testdata", "example.com/lib/tools", "example.com/lib/vendor", "x.org/v/v.go"} {
+		if ids[id] {
+			t.Errorf("node %s is not part of the workspace", id)
+		}
+	}
+
+	requires, _ := edgesByLabel(graph, "requires")
+	if edge, ok := requires["api -> lib"]; !ok || edge.Properties["version"] != "v0.1.0" {
+		t.Errorf("module requires edge is missing: %v", requires)
+	}
+
+	if pairs, _ := edgesByLabel(graph, "invokes"); pairs["Serve -> Hello"].Source == "" {
+		t.Errorf("cross-module invokes edge is missing: %v", pairs)
+	}
+}
+
+func TestVendoredPackages(t *testing.T) {
+	files := map[string]string{
+		"go.mod":                        "module example.com/app\n\ngo 1.21\n\nrequire example.com/dep v1.0.0\n",
+		"app.go":                        "package app\n\nimport \"example.com/dep\"\n\nfunc Run() { dep.Do() }\n",
+		"vendor/modules.txt":            "# example.com/dep v1.0.0\n## explicit\nexample.com/dep\n",
+		"vendor/example.com/dep/dep.go": "package dep\n\nfunc Do() {}\n",
+	}
+
+	hasNode := func(graph *extractor.Graph, id string) bool {
+		for _, node := range graph.Elements.Nodes {
+			if node.Data.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	if graph := extractModule(t, files, extractor.Options{}); hasNode(graph, "example.com/dep/dep.go") {
+		t.Error("vendored file is extracted without Vendor")
+	}
+
+	graph := extractModule(t, files, extractor.Options{Vendor: true})
+	for _, id := range []string{"example.com/app/vendor", "example.com/dep/dep.go", "example.com/dep.Do"} {
+		if !hasNode(graph, id) {
+			t.Errorf("node %s is missing with Vendor", id)
+		}
+	}
+	if pairs, _ := edgesByLabel(graph, "invokes"); pairs["Run -> Do"].Source == "" {
+		t.Errorf("invokes edge into the vendored package is missing: %v", pairs)
+	}
+}
+
+func TestExternalElements(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"app/go.mod": "module example.com/app\n\ngo 1.21\n\nrequire example.com/db v1.2.0\n\nreplace example.com/db => ../db\n",
+		"app/store.go": `package app
+
+import (
+	"database/sql"
+
+	"example.com/db"
+)
+
+func Open() (*sql.DB, error) { return sql.Open(db.Driver, "") }
+
+func Close(conn *sql.DB) { conn.Close() }
+
+func Name() string { return db.Name() }
+`,
+		"db/go.mod": "module example.com/db\n\ngo 1.21\n",
+		"db/db.go":  "package db\n\nconst Driver = \"memory\"\n\nfunc Name() string { return Driver }\n",
+	})
+
+	graph, err := extractor.Extract(context.Background(), filepath.Join(root, "app"), extractor.Options{External: true})
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	nodes := map[string]extractor.NodeData{}
+	for _, node := range graph.Elements.Nodes {
+		nodes[node.Data.ID] = node.Data
+	}
+	if labels := nodes["external:database/sql"].Labels; !slices.Equal(labels, []string{"External", "Stdlib"}) {
+		t.Errorf("database/sql labels = %v", labels)
+	}
+	db := nodes["external:example.com/db"]
+	if !slices.Equal(db.Labels, []string{"External", "ThirdParty"}) || db.Properties["module"] != "example.com/db" || db.Properties["version"] != "v1.2.0" {
+		t.Errorf("example.com/db node = %+v", db)
+	}
+
+	// Which operations touch database/sql?
+	touching := map[string]bool{}
+	for _, edge := range graph.Elements.Edges {
+		if strings.HasPrefix(edge.Data.Target, "external:database/sql.") {
+			touching[edge.Data.Source+" "+edge.Data.Label+" "+edge.Data.Target] = true
+		}
+	}
+	for _, want := range []string{
+		"example.com/app.Open invokes external:database/sql.Open",
+		"example.com/app.Close invokes external:database/sql.DB.Close",
+	} {
+		if !touching[want] {
+			t.Errorf("missing %s in %v", want, touching)
+		}
+	}
+
+	pairs, _ := edgesByLabel(graph, "uses")
+	if pairs["Open -> Driver"].Properties["access"] != "read" {
+		t.Errorf("uses edge to the external constant is missing: %v", pairs)
+	}
+	if pairs, _ := edgesByLabel(graph, "requires"); pairs["store.go -> db"].Source == "" {
+		t.Errorf("requires edge to the imported package is missing: %v", pairs)
+	}
+}
+
+func TestExtractIsIndependentOfWorkers(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"go.mod":       "module example.com/par\n\ngo 1.21\n",
+		"a/a.go":       "package a\n\nvar Count int\n\nfunc Inc() { Count++ }\n",
+		"b/b.go":       "package b\n\nimport \"example.com/par/a\"\n\ntype T struct{ N int }\n\nfunc (t T) Run() { a.Inc() }\n",
+		"c/c.go":       "package c\n\nimport \"example.com/par/b\"\n\nfunc Start() { go b.T{}.Run() }\n",
+		"cmd/main.go":  "package main\n\nimport \"example.com/par/c\"\n\nfunc main() { c.Start() }\n",
+		"cmd/extra.go": "package main\n\nconst Name = \"par\"\n",
+	})
+
+	// ids lists the node and edge IDs of graph in a stable order
+	ids := func(graph *extractor.Graph) []string {
+		var ids []string
+		for _, node := range graph.Elements.Nodes {
+			ids = append(ids, node.Data.ID)
+		}
+		for _, edge := range graph.Elements.Edges {
+			ids = append(ids, edge.Data.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	var want []string
+	for _, workers := range []int{1, 8} {
+		graph, err := extractor.Extract(context.Background(), root, extractor.Options{Workers: workers, External: true})
+		if err != nil {
+			t.Fatalf("Extract with %d workers failed: %v", workers, err)
+		}
+		if want == nil {
+			want = ids(graph)
+		} else if got := ids(graph); !slices.Equal(got, want) {
+			t.Errorf("%d workers produced a different graph:\n%v\nwant\n%v", workers, got, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := extractor.Extract(ctx, root, extractor.Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Extract with a cancelled context returned %v", err)
+	}
+}
+
+func TestExtractionCache(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"go.mod":     "module example.com/cached\n\ngo 1.21\n",
+		"base/b.go":  "package base\n\ntype Valuer interface{ Value() int }\n\nfunc Value() int { return 1 }\n",
+		"user/u.go":  "package user\n\nimport \"example.com/cached/base\"\n\nfunc Use() int { return base.Value() }\n",
+		"other/o.go": "package other\n\ntype Alone struct{}\n\nfunc (Alone) Value() int { return 0 }\n",
+	})
+	cacheDir := t.TempDir()
+
+	// entries lists the cached package entries
+	entries := func() map[string]bool {
+		found := map[string]bool{}
+		paths, _ := filepath.Glob(filepath.Join(cacheDir, "packages", "*", "*.json"))
+		for _, path := range paths {
+			found[filepath.Base(path)] = true
+		}
+		return found
+	}
+	extract := func(opts extractor.Options) []string {
+		graph, err := extractor.Extract(context.Background(), root, opts)
+		if err != nil {
+			t.Fatalf("Extract failed: %v", err)
+		}
+		var ids []string
+		for _, edge := range graph.Elements.Edges {
+			ids = append(ids, edge.Data.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	implements := "example.com/cached/other.Alone->example.com/cached/base.Valuer.implements"
+	if first := extract(extractor.Options{CacheDir: cacheDir}); !slices.Contains(first, implements) {
+		t.Errorf("implements edge is missing: %v", first)
+	}
+	before := entries()
+	if len(before) != 3 {
+		t.Fatalf("cached %d packages, want 3", len(before))
+	}
+
+	// Changing base invalidates base and user, which imports it, but not
+	// other, whose type no longer implements the interface of base
+	if err := os.WriteFile(filepath.Join(root, "base", "b.go"), []byte("package base\n\ntype Valuer interface{ Value() int64 }\n\nfunc Value() int { return helper() }\n\nfunc helper() int { return 2 }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cached := extract(extractor.Options{CacheDir: cacheDir})
+	added := 0
+	for name := range entries() {
+		if !before[name] {
+			added++
+		}
+	}
+	if added != 2 {
+		t.Errorf("recomputed %d packages, want 2", added)
+	}
+
+	if fresh := extract(extractor.Options{}); !slices.Equal(cached, fresh) {
+		t.Errorf("cached extraction differs from a fresh one:\n%v\nwant\n%v", cached, fresh)
+	}
+	if !slices.Contains(cached, "example.com/cached/base.Value->example.com/cached/base.helper:invokes") {
+		t.Errorf("edge of the changed package is missing: %v", cached)
+	}
+	if slices.Contains(cached, implements) {
+		t.Errorf("implements edge of an unchanged package outlived the interface")
+	}
+
+	// A graph assembled from cached packages only, after a change of the
+	// tree that no package sees, is the same as a fresh one
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("# cached\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "docs"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "doc.go"), []byte("//go:build ignore\n\npackage docs\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assembled := extract(extractor.Options{CacheDir: cacheDir})
+	if fresh := extract(extractor.Options{}); !slices.Equal(assembled, fresh) {
+		t.Errorf("assembled extraction differs from a fresh one:\n%v\nwant\n%v", assembled, fresh)
+	}
+
+	// Five package entries and three graphs
+	if removed, err := extractor.PruneCache(cacheDir, 0); err != nil || removed != 8 || len(entries()) != 0 {
+		t.Errorf("PruneCache removed %d entries (%v), %d packages left", removed, err, len(entries()))
+	}
+}
+
+func TestSelfExtractionHasUniqueIDs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("extracts the whole repository")
+	}
+	graph, err := extractor.Extract(context.Background(), "..", extractor.Options{})
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	nodes := make(map[string]bool)
+	for _, node := range graph.Elements.Nodes {
+		if nodes[node.Data.ID] {
+			t.Errorf("duplicate node ID %s", node.Data.ID)
+		}
+		nodes[node.Data.ID] = true
+	}
+	edges := make(map[string]bool)
+	for _, edge := range graph.Elements.Edges {
+		if edges[edge.Data.ID] {
+			t.Errorf("duplicate edge ID %s", edge.Data.ID)
+		}
+		edges[edge.Data.ID] = true
+	}
+	if nodes["github.com/rayhanp1402/gophers/extractor.string"] {
+		t.Error("the type of a package-level variable became a node")
+	}
+}
@@ -0,0 +1,390 @@
+package extractor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Module is a Go module that is part of the extracted project.
+type Module struct {
+	// Path is the module path declared by go.mod.
+	Path string
+
+	// Dir is the absolute directory holding go.mod.
+	Dir string
+
+	// GoVersion is the version of the go directive, if any.
+	GoVersion string
+
+	// Requires maps the module paths required by go.mod to their versions.
+	Requires map[string]string
+}
+
+// moduleNodeID identifies the Module node of a module path.
+func moduleNodeID(modulePath string) string {
+	return "module:" + modulePath
+}
+
+// skipDir tells whether the go command ignores the directory name when
+// matching ./..., along with the directories Gophers writes into. vendor is
+// also skipped; vendored packages are only extracted on request.
+func skipDir(name string) bool {
+	return name == "vendor" || name == "testdata" || name == "intermediate_representation" ||
+		strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// projectModules returns the modules that make up the project rooted at
+// root, sorted by directory, and whether they are the modules of a go.work
+// workspace at root. Without a workspace every go.mod below root counts.
+func projectModules(root string) ([]Module, bool, error) {
+	var dirs []string
+	workspace := false
+
+	if data, err := os.ReadFile(filepath.Join(root, "go.work")); err == nil {
+		work, err := modfile.ParseWork(filepath.Join(root, "go.work"), data, nil)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse go.work: %w", err)
+		}
+		for _, use := range work.Use {
+			dir := use.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(root, filepath.FromSlash(dir))
+			}
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+		workspace = true
+	} else {
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && p != root && skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && info.Name() == "go.mod" {
+				dirs = append(dirs, filepath.Dir(p))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	var modules []Module
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read go.mod of %s: %w", dir, err)
+		}
+		file, err := modfile.ParseLax(filepath.Join(dir, "go.mod"), data, nil)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse go.mod of %s: %w", dir, err)
+		}
+		if file.Module == nil {
+			continue
+		}
+
+		mod := Module{Path: file.Module.Mod.Path, Dir: dir, Requires: make(map[string]string)}
+		if file.Go != nil {
+			mod.GoVersion = file.Go.Version
+		}
+		for _, req := range file.Require {
+			mod.Requires[req.Mod.Path] = req.Mod.Version
+		}
+		modules = append(modules, mod)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Dir < modules[j].Dir })
+
+	return modules, workspace, nil
+}
+
+// vendoredPackages returns the import paths of the packages vendored in
+// dir/vendor, as listed by vendor/modules.txt.
+func vendoredPackages(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, "vendor", "modules.txt"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkgs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			pkgs = append(pkgs, line)
+		}
+	}
+	return pkgs, scanner.Err()
+}
+
+// projectLayout maps the directories and files of a project to the IDs of
+// their Folder and File nodes. Paths inside a module are named after the
+// module path, and vendored paths after the import path they provide, so
+// that they match the package paths the type checker reports.
+type projectLayout struct {
+	root           string
+	rootImportPath string
+	modules        []Module
+	workspace      bool
+}
+
+func newProjectLayout(root string) (*projectLayout, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	modules, workspace, err := projectModules(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &projectLayout{
+		root:           absRoot,
+		rootImportPath: importPathOf(absRoot),
+		modules:        modules,
+		workspace:      workspace,
+	}, nil
+}
+
+// module returns the innermost project module containing p.
+func (l *projectLayout) module(p string) (Module, bool) {
+	var best Module
+	found := false
+	for _, mod := range l.modules {
+		rel, err := filepath.Rel(mod.Dir, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(mod.Dir) > len(best.Dir) {
+			best, found = mod, true
+		}
+	}
+	return best, found
+}
+
+// nodeID returns the ID of the Folder or File node of p.
+func (l *projectLayout) nodeID(p string) string {
+	mod, ok := l.module(p)
+	if !ok {
+		return relativeNodeID(l.root, l.rootImportPath, p)
+	}
+	rel, err := filepath.Rel(mod.Dir, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	rel = filepath.ToSlash(rel)
+	if vendored, ok := strings.CutPrefix(rel, "vendor/"); ok {
+		return vendored
+	}
+	return path.Join(mod.Path, rel)
+}
+
+// walk visits the project's directories and .go files, leaving out the
+// directories the go command ignores and modules that are not part of a
+// go.work workspace. With vendor set it visits the vendor directories of the
+// modules instead, and only those.
+func (l *projectLayout) walk(vendor bool, fn func(p string, info os.FileInfo) error) error {
+	modules := make(map[string]bool)
+	for _, mod := range l.modules {
+		modules[mod.Dir] = true
+	}
+
+	if vendor {
+		for _, mod := range l.modules {
+			vendorDir := filepath.Join(mod.Dir, "vendor")
+			if _, err := os.Stat(vendorDir); err != nil {
+				continue
+			}
+			if err := filepath.Walk(vendorDir, func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() && filepath.Ext(p) != ".go" {
+					return nil
+				}
+				return fn(p, info)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return filepath.Walk(l.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && p != l.root {
+			if skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			// Nested modules left out of the workspace are not part of the project
+			if l.workspace && !modules[p] {
+				if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+		}
+		if !info.IsDir() && filepath.Ext(p) != ".go" {
+			return nil
+		}
+		return fn(p, info)
+	})
+}
+
+// projectTree returns the Folder and File nodes of the paths walk visits,
+// along with the Folder "contains" edges between them and the Project
+// "includes" edges to all of them.
+func (l *projectLayout) projectTree(vendor bool) ([]GraphNode, []GraphEdge, []GraphEdge, error) {
+	var nodes []GraphNode
+	var contains, includes []GraphEdge
+	projectNodeID := "project:" + l.rootImportPath
+	seen := map[string]bool{}
+
+	err := l.walk(vendor, func(p string, info os.FileInfo) error {
+		id := l.nodeID(p)
+
+		if !seen[id] {
+			label := "File"
+			if info.IsDir() {
+				label = "Folder"
+			}
+			nodes = append(nodes, GraphNode{
+				Data: NodeData{
+					ID:     id,
+					Labels: []string{label},
+					Properties: map[string]string{
+						"qualifiedName": id,
+						"simpleName":    filepath.Base(p),
+					},
+				},
+			})
+			seen[id] = true
+		}
+
+		if p != l.root {
+			parentID := l.nodeID(filepath.Dir(p))
+			contains = append(contains, GraphEdge{
+				Data: EdgeData{
+					ID:     fmt.Sprintf("%s->%s.contains", parentID, id),
+					Label:  "contains",
+					Source: parentID,
+					Target: id,
+					Properties: map[string]string{
+						"kind": "FolderContains",
+					},
+				},
+			})
+		}
+
+		includes = append(includes, GraphEdge{
+			Data: EdgeData{
+				ID:     projectNodeID + "_includes_" + id,
+				Label:  "includes",
+				Source: projectNodeID,
+				Target: id,
+				Properties: map[string]string{
+					"type": "includes",
+				},
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return nodes, contains, includes, nil
+}
+
+// GenerateModuleEdges links the project to its modules ("includes"), every
+// module to the folder holding its go.mod ("contains") and every module to
+// the project modules its go.mod requires ("requires", with the required
+// version).
+func GenerateModuleEdges(sourceRoot string) ([]GraphEdge, error) {
+	layout, err := newProjectLayout(sourceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var edges []GraphEdge
+	projectNodeID := "project:" + layout.rootImportPath
+	projectModules := make(map[string]bool)
+	for _, mod := range layout.modules {
+		projectModules[mod.Path] = true
+	}
+
+	for _, mod := range layout.modules {
+		moduleID := moduleNodeID(mod.Path)
+		folderID := layout.nodeID(mod.Dir)
+		edges = append(edges,
+			GraphEdge{
+				Data: EdgeData{
+					ID:     projectNodeID + "_includes_" + moduleID,
+					Label:  "includes",
+					Source: projectNodeID,
+					Target: moduleID,
+					Properties: map[string]string{
+						"type": "includes",
+					},
+				},
+			},
+			GraphEdge{
+				Data: EdgeData{
+					ID:     fmt.Sprintf("%s->%s.contains", moduleID, folderID),
+					Label:  "contains",
+					Source: moduleID,
+					Target: folderID,
+					Properties: map[string]string{
+						"kind": "ModuleContains",
+					},
+				},
+			},
+		)
+
+		for _, required := range sortedKeys(mod.Requires) {
+			if !projectModules[required] {
+				continue
+			}
+			targetID := moduleNodeID(required)
+			edges = append(edges, GraphEdge{
+				Data: EdgeData{
+					ID:     fmt.Sprintf("%s->%s.requires", moduleID, targetID),
+					Label:  "requires",
+					Source: moduleID,
+					Target: targetID,
+					Properties: map[string]string{
+						"version": mod.Requires[required],
+					},
+				},
+			})
+		}
+	}
+
+	return edges, nil
+}
+
+// generateVendorElements returns the Folder and File nodes of the vendor
+// directories of the project, with their "contains" and "includes" edges.
+func generateVendorElements(sourceRoot string) ([]GraphNode, []GraphEdge, error) {
+	layout, err := newProjectLayout(sourceRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, contains, includes, err := layout.projectTree(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, append(contains, includes...), nil
+}
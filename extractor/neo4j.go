@@ -0,0 +1,155 @@
+package extractor
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Neo4j CSV bundle file names written by WriteNeo4jCSV.
+const (
+	Neo4jNodesFile         = "nodes.csv"
+	Neo4jRelationshipsFile = "relationships.csv"
+)
+
+// WriteCypher writes graph as a Cypher script of MERGE statements. Nodes are
+// merged on their id under their first label, so running the script twice, or
+// on top of another extraction of the same project, does not duplicate them.
+// Every label and property is kept; properties are stored as strings.
+func WriteCypher(w io.Writer, graph *Graph) error {
+	bw := bufio.NewWriter(w)
+
+	primary := make(map[string]string)
+	labels := make(map[string]bool)
+	for _, node := range graph.Elements.Nodes {
+		label := nodeGroupLabel(node.Data)
+		primary[node.Data.ID] = label
+		labels[label] = true
+	}
+
+	for _, label := range sortedKeys(labels) {
+		fmt.Fprintf(bw, "CREATE CONSTRAINT IF NOT EXISTS FOR (n:%s) REQUIRE n.id IS UNIQUE;\n", cypherName(label))
+	}
+
+	for _, node := range graph.Elements.Nodes {
+		fmt.Fprintf(bw, "MERGE (n:%s {id: %s})", cypherName(primary[node.Data.ID]), cypherString(node.Data.ID))
+		for _, label := range node.Data.Labels[min(1, len(node.Data.Labels)):] {
+			fmt.Fprintf(bw, " SET n:%s", cypherName(label))
+		}
+		if len(node.Data.Properties) > 0 {
+			fmt.Fprintf(bw, " SET n += %s", cypherMap(node.Data.Properties))
+		}
+		bw.WriteString(";\n")
+	}
+
+	for _, edge := range graph.Elements.Edges {
+		source, target := primary[edge.Data.Source], primary[edge.Data.Target]
+		if source == "" || target == "" {
+			// MATCH would silently find nothing; say so in the script instead
+			fmt.Fprintf(bw, "// skipped %s: missing endpoint\n", edge.Data.ID)
+			continue
+		}
+		fmt.Fprintf(bw, "MATCH (a:%s {id: %s}), (b:%s {id: %s}) MERGE (a)-[r:%s {id: %s}]->(b)",
+			cypherName(source), cypherString(edge.Data.Source),
+			cypherName(target), cypherString(edge.Data.Target),
+			cypherName(edge.Data.Label), cypherString(edge.Data.ID))
+		if len(edge.Data.Properties) > 0 {
+			fmt.Fprintf(bw, " SET r += %s", cypherMap(edge.Data.Properties))
+		}
+		bw.WriteString(";\n")
+	}
+
+	return bw.Flush()
+}
+
+// cypherName quotes a label, relationship type or property key.
+func cypherName(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// cypherString quotes a string literal.
+func cypherString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return "'" + replacer.Replace(s) + "'"
+}
+
+func cypherMap(props map[string]string) string {
+	entries := make([]string, 0, len(props))
+	for _, key := range sortedKeys(props) {
+		entries = append(entries, cypherName(key)+": "+cypherString(props[key]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// WriteNeo4jCSV writes graph into dir as the nodes and relationships CSV
+// files accepted by `neo4j-admin database import full`:
+//
+//	neo4j-admin database import full --nodes=nodes.csv --relationships=relationships.csv
+//
+// Multiple labels are joined with ";" in the :LABEL column. Every property key
+// used by any node or edge becomes a column; missing values are left empty,
+// which the importer treats as absent.
+func WriteNeo4jCSV(dir string, graph *Graph) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	nodeKeys, edgeKeys := propertyKeys(graph)
+	nodeColumns := withoutKey(nodeKeys, "id")
+
+	var nodeRows [][]string
+	nodeRows = append(nodeRows, append([]string{"id:ID", ":LABEL"}, nodeColumns...))
+	for _, node := range graph.Elements.Nodes {
+		row := []string{node.Data.ID, strings.Join(node.Data.Labels, ";")}
+		for _, key := range nodeColumns {
+			row = append(row, node.Data.Properties[key])
+		}
+		nodeRows = append(nodeRows, row)
+	}
+
+	edgeColumns := withoutKey(edgeKeys, "id")
+
+	var edgeRows [][]string
+	edgeRows = append(edgeRows, append([]string{":START_ID", ":END_ID", ":TYPE", "id"}, edgeColumns...))
+	for _, edge := range graph.Elements.Edges {
+		row := []string{edge.Data.Source, edge.Data.Target, edge.Data.Label, edge.Data.ID}
+		for _, key := range edgeColumns {
+			row = append(row, edge.Data.Properties[key])
+		}
+		edgeRows = append(edgeRows, row)
+	}
+
+	if err := writeCSVFile(filepath.Join(dir, Neo4jNodesFile), nodeRows); err != nil {
+		return err
+	}
+	return writeCSVFile(filepath.Join(dir, Neo4jRelationshipsFile), edgeRows)
+}
+
+// withoutKey drops key from keys, as it is already covered by a fixed column.
+func withoutKey(keys []string, key string) []string {
+	var kept []string
+	for _, k := range keys {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+func writeCSVFile(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
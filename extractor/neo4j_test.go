@@ -0,0 +1,80 @@
+package extractor_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+func exportTestGraph() *extractor.Graph {
+	return &extractor.Graph{Elements: extractor.Elements{
+		Nodes: []extractor.GraphNode{
+			{Data: extractor.NodeData{ID: "m.Run", Labels: []string{"Operation", "Type"}, Properties: map[string]string{"simpleName": "Run", "doc": "it's"}}},
+			{Data: extractor.NodeData{ID: "m.Stop", Labels: []string{"Operation"}, Properties: map[string]string{"simpleName": "Stop"}}},
+		},
+		Edges: []extractor.GraphEdge{
+			testEdge("m.Run", "m.Stop", "invokes", map[string]string{"line": "3"}),
+		},
+	}}
+}
+
+func TestWriteCypher(t *testing.T) {
+	var out bytes.Buffer
+	if err := extractor.WriteCypher(&out, exportTestGraph()); err != nil {
+		t.Fatalf("WriteCypher failed: %v", err)
+	}
+	script := out.String()
+
+	for _, want := range []string{
+		"MERGE (n:`Operation` {id: 'm.Run'}) SET n:`Type` SET n += {`doc`: 'it\\'s', `simpleName`: 'Run'};",
+		"MATCH (a:`Operation` {id: 'm.Run'}), (b:`Operation` {id: 'm.Stop'}) MERGE (a)-[r:`invokes` {id: 'm.Run->m.Stop.invokes'}]->(b) SET r += {`line`: '3'};",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script lacks %s\n%s", want, script)
+		}
+	}
+}
+
+func TestWriteNeo4jCSV(t *testing.T) {
+	dir := t.TempDir()
+	if err := extractor.WriteNeo4jCSV(dir, exportTestGraph()); err != nil {
+		t.Fatalf("WriteNeo4jCSV failed: %v", err)
+	}
+
+	read := func(name string) [][]string {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		defer f.Close()
+		rows, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return rows
+	}
+
+	nodes := read(extractor.Neo4jNodesFile)
+	if got := strings.Join(nodes[0], ","); got != "id:ID,:LABEL,doc,simpleName" {
+		t.Errorf("nodes header = %s", got)
+	}
+	if got := strings.Join(nodes[1], ","); got != "m.Run,Operation;Type,it's,Run" {
+		t.Errorf("first node row = %s", got)
+	}
+	if got := strings.Join(nodes[2], ","); got != "m.Stop,Operation,,Stop" {
+		t.Errorf("second node row = %s", got)
+	}
+
+	relationships := read(extractor.Neo4jRelationshipsFile)
+	if got := strings.Join(relationships[0], ","); got != ":START_ID,:END_ID,:TYPE,id,line" {
+		t.Errorf("relationships header = %s", got)
+	}
+	if got := strings.Join(relationships[1], ","); got != "m.Run,m.Stop,invokes,m.Run->m.Stop.invokes,3" {
+		t.Errorf("relationship row = %s", got)
+	}
+}
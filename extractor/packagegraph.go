@@ -0,0 +1,156 @@
+package extractor
+
+import (
+	"go/token"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// packageGraph is the part of the graph a package contributes, which the
+// cache keeps so that unchanged packages are neither loaded nor generated
+// again: the nodes declared in its files and its Scope node, the edges
+// leaving them or its File nodes, the External nodes and edges they lead to,
+// and the method sets its implements edges are computed from.
+type packageGraph struct {
+	Nodes      []GraphNode `json:"nodes"`
+	Edges      []GraphEdge `json:"edges"`
+	MethodSets []methodSet `json:"methodSets,omitempty"`
+}
+
+// splitGraph divides the nodes and edges generated for pkgs into the graph
+// of every package and what belongs to the project as a whole: the Project,
+// Module, Folder and File nodes and the edges leaving all but File nodes.
+// Implements edges are left to implementsEdges, which compares the method
+// sets of every package.
+func splitGraph(
+	fset *token.FileSet,
+	pkgs []*packages.Package,
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	nodes []GraphNode,
+	edges []GraphEdge,
+	sets map[string][]methodSet,
+) (map[string]*packageGraph, []GraphNode, []GraphEdge) {
+	graphs := make(map[string]*packageGraph, len(pkgs))
+	fileOwners := make(map[string]string)  // by File node ID
+	scopeOwners := make(map[string]string) // by Scope node ID
+	for _, pkg := range pkgs {
+		graphs[pkg.ID] = &packageGraph{MethodSets: sets[pkg.ID]}
+		for _, f := range pkg.Syntax {
+			root, ok := simplifiedASTs[fset.Position(f.Package).Filename]
+			if !ok {
+				continue
+			}
+			fileOwners[rootFileID(root)] = pkg.ID
+			for _, child := range root.Children {
+				if child.Type == "Package" && child.Name != "" {
+					scopeOwners[packageNodeID(child)] = pkg.ID
+				}
+			}
+		}
+	}
+
+	var projectNodes []GraphNode
+	owners := make(map[string]string)      // by node ID
+	external := make(map[string]GraphNode) // by node ID
+	for _, node := range nodes {
+		id := node.Data.ID
+		if slices.Contains(node.Data.Labels, "External") {
+			external[id] = node
+			continue
+		}
+		owner, ok := fileOwners[node.Data.Properties["file"]]
+		if !ok {
+			owner, ok = scopeOwners[id]
+		}
+		if !ok {
+			projectNodes = append(projectNodes, node)
+			continue
+		}
+		owners[id] = owner
+		graphs[owner].Nodes = append(graphs[owner].Nodes, node)
+	}
+
+	// External nodes and the encloses edges between them go to every
+	// package whose edges lead to them
+	var projectEdges []GraphEdge
+	encloses := make(map[string][]GraphEdge) // by target
+	for _, edge := range edges {
+		if edge.Data.Label == "implements" {
+			continue
+		}
+		source := edge.Data.Source
+		if _, ok := external[source]; ok {
+			encloses[edge.Data.Target] = append(encloses[edge.Data.Target], edge)
+			continue
+		}
+		owner, ok := owners[source]
+		if !ok {
+			owner, ok = fileOwners[source]
+		}
+		if !ok {
+			projectEdges = append(projectEdges, edge)
+			continue
+		}
+		graphs[owner].Edges = append(graphs[owner].Edges, edge)
+	}
+	for _, graph := range graphs {
+		added := make(map[string]bool)
+		var add func(id string)
+		add = func(id string) {
+			node, ok := external[id]
+			if !ok || added[id] {
+				return
+			}
+			added[id] = true
+			graph.Nodes = append(graph.Nodes, node)
+			for _, edge := range encloses[id] {
+				graph.Edges = append(graph.Edges, edge)
+				add(edge.Data.Source)
+			}
+		}
+		for _, edge := range graph.Edges {
+			add(edge.Data.Target)
+		}
+	}
+
+	return graphs, projectNodes, projectEdges
+}
+
+// assembleGraph joins the nodes and edges of the project as a whole with the
+// graphs of its packages. External elements shared by several packages are
+// kept once.
+func assembleGraph(nodes []GraphNode, edges []GraphEdge, graphs []*packageGraph) *Graph {
+	external := make(map[string]bool)
+	externalEdges := make(map[string]bool)
+	var sets []methodSet
+	for _, graph := range graphs {
+		for _, node := range graph.Nodes {
+			if slices.Contains(node.Data.Labels, "External") {
+				if external[node.Data.ID] {
+					continue
+				}
+				external[node.Data.ID] = true
+			}
+			nodes = append(nodes, node)
+		}
+		for _, edge := range graph.Edges {
+			if external[edge.Data.Source] {
+				if externalEdges[edge.Data.ID] {
+					continue
+				}
+				externalEdges[edge.Data.ID] = true
+			}
+			edges = append(edges, edge)
+		}
+		sets = append(sets, graph.MethodSets...)
+	}
+	edges = append(edges, implementsEdges(sets)...)
+
+	return &Graph{
+		Elements: Elements{
+			Nodes: nodes,
+			Edges: edges,
+		},
+	}
+}
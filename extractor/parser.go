@@ -1,7 +1,9 @@
 package extractor
 
 import (
+	"context"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
 	"os"
@@ -10,25 +12,27 @@ import (
 
 // Parses a whole package (only the .go files) into a FileSet
 // dir is relative to this (gophers) package
+// Files excluded by their name or //go:build line for the default build
+// context are skipped, as the go command would, and so are the directories
+// it ignores, such as vendor and testdata.
 func ParsePackage(dir string) (*token.FileSet, map[string]*ast.File, error) {
     fset := token.NewFileSet()
 
-    files := make(map[string]*ast.File)
+    var paths []string
 
     err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-        if filepath.Ext(path) == ".go" {
-            file, err := os.Open(path)
-            if err != nil {
-                return err
-            }
-            defer file.Close()
+        if err != nil {
+            return err
+        }
+        if info.IsDir() && path != dir && skipDir(info.Name()) {
+            return filepath.SkipDir
+        }
 
-            astFile, err := parser.ParseFile(fset, path, file, parser.AllErrors)
-            if err != nil {
-                return err
+        if filepath.Ext(path) == ".go" {
+            if match, err := build.Default.MatchFile(filepath.Dir(path), filepath.Base(path)); err == nil && !match {
+                return nil
             }
-    
-            files[path] = astFile
+            paths = append(paths, path)
         }
 
         return nil
@@ -38,5 +42,25 @@ func ParsePackage(dir string) (*token.FileSet, map[string]*ast.File, error) {
         return nil, nil, err
     }
 
+    // Files are parsed concurrently; the FileSet is safe for concurrent use
+    parsed := make([]*ast.File, len(paths))
+    err = parallel(context.Background(), Options{}.workers(), len(paths), func(i int) error {
+        astFile, err := parser.ParseFile(fset, paths[i], nil, parser.AllErrors)
+        if err != nil {
+            return err
+        }
+        parsed[i] = astFile
+        return nil
+    })
+
+    if err != nil {
+        return nil, nil, err
+    }
+
+    files := make(map[string]*ast.File, len(paths))
+    for i, path := range paths {
+        files[path] = parsed[i]
+    }
+
     return fset, files, nil
-}
\ No newline at end of file
+}
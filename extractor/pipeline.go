@@ -0,0 +1,350 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Options controls how Extract builds a knowledge graph.
+type Options struct {
+	// IntermediateDir, when set, receives every simplified AST as a
+	// .simplified.json file mirroring the project layout.
+	IntermediateDir string
+
+	// SymbolTableFile, when set, receives a plaintext dump of the symbol table.
+	SymbolTableFile string
+
+	// CallGraph selects the call graph algorithm (CallGraphCHA, CallGraphRTA
+	// or CallGraphVTA) used to add invokes edges for interface and
+	// function-value calls. Leave empty to only link statically known calls.
+	CallGraph string
+
+	// Inherits adds "inherits" edges from named types to the methods and
+	// fields promoted to them through embedding.
+	Inherits bool
+
+	// Tests also loads _test.go files, in-package and external test packages
+	// alike, and adds "tests" edges from test functions to the production
+	// operations they exercise.
+	Tests bool
+
+	// TestDepth is how many invokes edges a "tests" edge may span; 1, the
+	// minimum, only links the operations a test calls directly.
+	TestDepth int
+
+	// BuildContexts selects the target platform and build tags files are
+	// matched against; an empty list means the environment's defaults. With
+	// more than one context the project is extracted once per context and
+	// the graphs are merged with MergeGraphs. Debug output then reflects the
+	// last context only.
+	BuildContexts []BuildContext
+
+	// Vendor also extracts the packages vendored under vendor/ directories,
+	// which are otherwise left out like the go command leaves them out of
+	// ./... patterns.
+	Vendor bool
+
+	// External adds External nodes for imported standard library and
+	// third-party packages and for the symbols of theirs the project calls
+	// or uses. See GenerateExternalElements.
+	External bool
+
+	// Workers bounds how many files are simplified and how many edge
+	// generators run at once; zero means GOMAXPROCS.
+	Workers int
+
+	// CacheDir, when set, keeps the simplified ASTs and the part of the graph
+	// of every package, and the extracted graphs, in a content-addressed
+	// cache in this directory. A later extraction of an unchanged project
+	// returns the cached graph without type-checking, and otherwise only
+	// loads and generates the packages that changed and those depending on
+	// them. A call graph spans the whole program, so with CallGraph only the
+	// simplified ASTs are reused. Debug output bypasses the cached graphs.
+	CacheDir string
+}
+
+// Extract builds the knowledge graph of the Go project rooted at dir.
+// The simplified ASTs, symbol table and graph are kept in memory end to end;
+// disk is only touched when opts asks for debug output.
+func Extract(ctx context.Context, dir string, opts Options) (*Graph, error) {
+	if len(opts.BuildContexts) > 1 {
+		return extractContexts(ctx, dir, opts)
+	}
+
+	absPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	// Unchanged packages are assembled from their cached graphs, unless the
+	// call graph, which spans the whole program, or debug output is asked for
+	var cache *extractionCache
+	var cachedGraphs map[string]*packageGraph
+	if opts.CacheDir != "" {
+		if cache, err = newExtractionCache(ctx, opts.CacheDir, absPath, opts); err != nil {
+			return nil, fmt.Errorf("failed to compute cache keys: %w", err)
+		}
+		if opts.IntermediateDir == "" && opts.SymbolTableFile == "" {
+			if graph, ok := cache.graph(); ok {
+				return graph, nil
+			}
+			if opts.CallGraph == "" {
+				cachedGraphs = cache.packageGraphs()
+			}
+		}
+	}
+
+	workers := opts.workers()
+	fset := token.NewFileSet()
+	var pkgs []*packages.Package
+	if cachedGraphs == nil {
+		if pkgs, err = loadPackages(ctx, fset, absPath, nil, opts); err != nil {
+			return nil, err
+		}
+	} else if changed := cache.changedPackages(cachedGraphs); len(changed) > 0 {
+		loaded, err := loadPackagesMode(ctx, fset, absPath, declarationsOnly(changed), opts, loadMode, changed)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = withProjectImports(loaded, cache.byPath)
+	}
+
+	parsedFiles, typesInfo := PackageFiles(fset, pkgs)
+
+	var cachedASTs map[string]*SimplifiedASTNode
+	if cache != nil {
+		cachedASTs = cache.simplifiedASTs(pkgs)
+	}
+	simplifiedASTs, err := buildSimplifiedASTs(ctx, fset, parsedFiles, typesInfo, cachedASTs, workers)
+	if err != nil {
+		return nil, err
+	}
+	symbolTable, err := buildSymbolTable(ctx, simplifiedASTs, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.IntermediateDir != "" {
+		for _, root := range simplifiedASTs {
+			if err := SaveSimplifiedAST(root, absPath, opts.IntermediateDir); err != nil {
+				return nil, fmt.Errorf("failed to write simplified AST: %w", err)
+			}
+		}
+	}
+	if opts.SymbolTableFile != "" {
+		if err := WriteSymbolTableToFile(symbolTable, opts.SymbolTableFile); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes, err := GenerateGraphNodes(absPath, parsedFiles, symbolTable, simplifiedASTs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate graph nodes: %w", err)
+	}
+	var edges []GraphEdge
+	if opts.Vendor {
+		vendorNodes, vendorEdges, err := generateVendorElements(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk vendor directories: %w", err)
+		}
+		nodes = append(nodes, vendorNodes...)
+		edges = append(edges, vendorEdges...)
+	}
+
+	// The generators only read what they are given and run concurrently
+	var sets map[string][]methodSet
+	generators := allEdgeGenerators(simplifiedASTs, symbolTable, absPath)
+	generators = append(generators,
+		func() []GraphEdge {
+			sets = methodSets(fset, pkgs, symbolTable)
+			return nil
+		},
+		func() []GraphEdge { return GenerateInstantiatesEdges(fset, pkgs, simplifiedASTs, symbolTable) },
+		func() []GraphEdge { return GenerateEnumEdges(fset, pkgs, symbolTable) },
+	)
+	if opts.Inherits {
+		generators = append(generators, func() []GraphEdge {
+			return GenerateInheritsEdges(fset, pkgs, simplifiedASTs, symbolTable)
+		})
+	}
+	var externalNodes []GraphNode
+	if opts.External {
+		generators = append(generators, func() []GraphEdge {
+			var externalEdges []GraphEdge
+			externalNodes, externalEdges = GenerateExternalElements(pkgs, simplifiedASTs, symbolTable)
+			return externalEdges
+		})
+	}
+	generated, err := runEdgeGenerators(ctx, workers, generators)
+	if err != nil {
+		return nil, err
+	}
+	nodes = append(nodes, externalNodes...)
+	edges = append(edges, generated...)
+
+	// The call graph skips the calls already linked, so it runs last
+	if opts.CallGraph != "" {
+		callEdges, err := GenerateCallGraphEdges(fset, pkgs, simplifiedASTs, symbolTable, opts.CallGraph, edges)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build call graph: %w", err)
+		}
+		edges = append(edges, callEdges...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var graphs map[string]*packageGraph
+	if cache != nil && opts.CallGraph == "" {
+		var projectNodes []GraphNode
+		var projectEdges []GraphEdge
+		graphs, projectNodes, projectEdges = splitGraph(fset, pkgs, simplifiedASTs, nodes, edges, sets)
+		if cachedGraphs != nil {
+			// The packages loaded for their imports alone keep their cached
+			// graphs
+			var parts []*packageGraph
+			for _, pkg := range cache.pkgs {
+				part, ok := cachedGraphs[pkg.ID]
+				if !ok {
+					part, ok = graphs[pkg.ID]
+				}
+				if !ok {
+					log.Printf("package %s was not loaded and is missing from the graph", pkg.ID)
+					continue
+				}
+				parts = append(parts, part)
+			}
+			graph := assembleGraph(projectNodes, projectEdges, parts)
+			nodes, edges = graph.Elements.Nodes, graph.Elements.Edges
+		}
+	}
+	if cachedGraphs == nil {
+		var flat []methodSet
+		for _, pkgSets := range sets {
+			flat = append(flat, pkgSets...)
+		}
+		edges = append(edges, implementsEdges(flat)...)
+	}
+
+	// Tests edges follow the invokes edges of the whole graph
+	if opts.Tests {
+		edges = append(edges, GenerateTestsEdges(nodes, edges, max(opts.TestDepth, 1))...)
+	}
+
+	graph := &Graph{
+		Elements: Elements{
+			Nodes: nodes,
+			Edges: edges,
+		},
+	}
+
+	// Files left out by build constraints are still on disk, but not part of
+	// the project as built
+	excluded, err := opts.buildContext().excludedFiles(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match build constraints: %w", err)
+	}
+	withoutNodes(graph, excluded)
+
+	// A cache that cannot be written only costs the next run time
+	if cache != nil {
+		if err := cache.storePackages(fset, pkgs, simplifiedASTs, graphs); err != nil {
+			log.Printf("failed to cache packages: %v", err)
+		} else if err := cache.storeGraph(graph); err != nil {
+			log.Printf("failed to cache graph: %v", err)
+		}
+	}
+
+	return graph, nil
+}
+
+// declarationsOnly returns a go/packages parser that drops the function
+// bodies from every file but those of keep. Type-checking the packages keep
+// imports only takes their declarations, and skipping the bodies saves most
+// of the time loading them costs. Their graphs come from the cache anyway.
+func declarationsOnly(keep []*packages.Package) func(*token.FileSet, string, []byte) (*ast.File, error) {
+	files := make(map[string]bool)
+	for _, pkg := range keep {
+		for _, file := range pkg.CompiledGoFiles {
+			files[file] = true
+		}
+	}
+	return func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+		f, err := parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+		if f != nil && !files[filename] {
+			for _, decl := range f.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					fn.Body = nil
+				}
+			}
+		}
+		return f, err
+	}
+}
+
+// withProjectImports returns pkgs along with the project packages, listed in
+// byPath, they import directly or indirectly and that pkgs lacks. The
+// generators resolve references into those packages through their symbols.
+func withProjectImports(pkgs []*packages.Package, byPath map[string]*packages.Package) []*packages.Package {
+	loaded := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		loaded[pkg.PkgPath] = true
+	}
+	all := slices.Clone(pkgs)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if _, ok := byPath[pkg.PkgPath]; ok && !loaded[pkg.PkgPath] {
+			loaded[pkg.PkgPath] = true
+			all = append(all, pkg)
+		}
+	})
+	return all
+}
+
+// buildContext returns the single build context of opts.
+func (opts Options) buildContext() BuildContext {
+	if len(opts.BuildContexts) == 0 {
+		return BuildContext{}
+	}
+	return opts.BuildContexts[0]
+}
+
+// BuildSymbolTable merges the symbol tables of every simplified AST.
+func BuildSymbolTable(simplifiedASTs map[string]*SimplifiedASTNode) map[string]*ModifiedDefinitionInfo {
+	symbolTable, _ := buildSymbolTable(context.Background(), simplifiedASTs, Options{}.workers())
+	return symbolTable
+}
+
+// buildSymbolTable is BuildSymbolTable with the files' symbol tables
+// collected on up to workers goroutines until ctx is done. They are merged in
+// path order, so the result does not depend on scheduling.
+func buildSymbolTable(
+	ctx context.Context,
+	simplifiedASTs map[string]*SimplifiedASTNode,
+	workers int,
+) (map[string]*ModifiedDefinitionInfo, error) {
+	paths := sortedKeys(simplifiedASTs)
+	tables := make([]map[string]*ModifiedDefinitionInfo, len(paths))
+	err := parallel(ctx, workers, len(paths), func(i int) error {
+		tables[i] = CollectSymbolTable(simplifiedASTs[paths[i]])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	symbolTable := make(map[string]*ModifiedDefinitionInfo)
+	for _, table := range tables {
+		for name, def := range table {
+			symbolTable[name] = def
+		}
+	}
+	return symbolTable, nil
+}
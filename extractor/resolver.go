@@ -1,15 +1,18 @@
 package extractor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"go/ast"
-	"go/importer"
+	"go/parser"
 	"go/token"
 	"go/types"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -22,6 +25,9 @@ type SimplifiedASTNode struct {
 	Children []*SimplifiedASTNode `json:"children,omitempty"`
 	Position *ASTNodePosition     `json:"position,omitempty"`
 	DeclaredAt *ModifiedDefinitionInfo    `json:"declaredAt,omitempty"`
+	// Access is "write" or "addressTaken" for variable and field uses that
+	// are assigned or have their address taken; it is empty for reads.
+	Access string `json:"access,omitempty"`
 }
 
 type ASTNodePosition struct {
@@ -39,87 +45,314 @@ type ModifiedDefinitionInfo struct {
 	Type      string
 	ReceiverType string
 	PackageName  string
+	PackagePath  string
+	// QualifiedName identifies a definition independently of where the
+	// project lives on disk, e.g. example.com/app/handlers.Calculator.Sum.
+	QualifiedName string `json:",omitempty"`
+	// Value is the exact value of a constant.
+	Value string `json:",omitempty"`
 }
 
-func LoadTypesInfo(
-	fset *token.FileSet,
-	files map[string]*ast.File,
-	absPath string,
-) (*types.Info, *types.Package, error) {
-	cfg := &packages.Config{
-		Mode:  packages.NeedName | packages.NeedImports | packages.NeedTypes,
-		Fset:  fset,
-		Dir:   absPath,
-		Tests: false,
+// loadMode requests everything the extractor needs from go/packages: the
+// parsed syntax and the type information that goes with it. Dependencies are
+// type-checked from source so no compiler export data is required.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax |
+	packages.NeedTypesInfo | packages.NeedModule
+
+// LoadPackages loads every package under dir with go/packages. Each package
+// keeps its own syntax and type information, so packages sharing a name
+// (e.g. several `main` packages under cmd/) are never merged.
+func LoadPackages(dir string) (*token.FileSet, []*packages.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := loadPackages(context.Background(), fset, dir, nil, Options{})
+	if err != nil {
+		return nil, nil, err
 	}
+	return fset, pkgs, nil
+}
 
-	pkgs, err := packages.Load(cfg, "./...")
-	if err != nil || len(pkgs) == 0 {
-		return nil, nil, fmt.Errorf("failed to load packages: %w", err)
+// loadPackages loads the packages under dir; cancelling ctx stops the go
+// command. Only the fields of opts that select packages are used. A go.work
+// at dir loads its modules together; otherwise every module below dir is
+// loaded from its own directory, so that each resolves imports against its
+// own go.mod.
+func loadPackages(
+	ctx context.Context,
+	fset *token.FileSet,
+	dir string,
+	parseFile func(*token.FileSet, string, []byte) (*ast.File, error),
+	opts Options,
+) ([]*packages.Package, error) {
+	return loadPackagesMode(ctx, fset, dir, parseFile, opts, loadMode, nil)
+}
+
+// loadPackagesMode is loadPackages with the given go/packages mode. When
+// roots is not nil, only those packages, as returned by an earlier load, and
+// their dependencies are loaded.
+func loadPackagesMode(
+	ctx context.Context,
+	fset *token.FileSet,
+	dir string,
+	parseFile func(*token.FileSet, string, []byte) (*ast.File, error),
+	opts Options,
+	mode packages.LoadMode,
+	roots []*packages.Package,
+) ([]*packages.Package, error) {
+	modules, workspace, err := projectModules(dir)
+	if err != nil {
+		return nil, err
+	}
+	loadDirs := []string{dir}
+	if !workspace && len(modules) > 0 && (len(modules) > 1 || modules[0].Dir != dir) {
+		loadDirs = nil
+		for _, mod := range modules {
+			loadDirs = append(loadDirs, mod.Dir)
+		}
 	}
 
-	importer := importer.ForCompiler(fset, "source", nil)
+	rootPatterns := patternsByLoadDir(roots, loadDirs)
 
-	filesByPkg := map[string][]*ast.File{}
-	for _, f := range files {
-		pkgName := f.Name.Name
-		filesByPkg[pkgName] = append(filesByPkg[pkgName], f)
+	buildContext := opts.buildContext()
+	var pkgs []*packages.Package
+	for _, loadDir := range loadDirs {
+		patterns := []string{"./..."}
+		if roots != nil {
+			patterns = rootPatterns[loadDir]
+			if len(patterns) == 0 {
+				continue
+			}
+		} else if workspace {
+			// ./... only matches a workspace's modules from within them
+			patterns = nil
+			for _, mod := range modules {
+				rel, err := filepath.Rel(loadDir, mod.Dir)
+				if err != nil {
+					return nil, fmt.Errorf("failed to locate module %s: %w", mod.Path, err)
+				}
+				patterns = append(patterns, "./"+path.Join(filepath.ToSlash(rel), "..."))
+			}
+		}
+		buildFlags := buildContext.buildFlags()
+		if opts.Vendor {
+			vendored, err := vendoredPackages(loadDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read vendor/modules.txt: %w", err)
+			}
+			if len(vendored) > 0 {
+				if roots == nil {
+					patterns = append(patterns, vendored...)
+				}
+				buildFlags = append(buildFlags, "-mod=vendor")
+			}
+		}
+		if workspace && !slices.Contains(buildFlags, "-mod=vendor") {
+			// Workspaces reject -mod=mod, which GOFLAGS may carry
+			buildFlags = append(buildFlags, "-mod=readonly")
+		}
+
+		cfg := &packages.Config{
+			Context:    ctx,
+			Mode:       mode,
+			Fset:       fset,
+			Dir:        loadDir,
+			Env:        buildContext.environ(),
+			BuildFlags: buildFlags,
+			Tests:      opts.Tests,
+			ParseFile:  parseFile,
+		}
+
+		loaded, err := packages.Load(cfg, patterns...)
+		if ctx.Err() != nil {
+			// go/packages does not wrap the context's error
+			return nil, fmt.Errorf("failed to load packages: %w", ctx.Err())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load packages: %w", err)
+		}
+		pkgs = append(pkgs, loaded...)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages found in %s", dir)
 	}
 
-	mergedInfo := &types.Info{
-		Defs:       make(map[*ast.Ident]types.Object),
-		Uses:       make(map[*ast.Ident]types.Object),
-		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	if opts.Tests {
+		pkgs = testVariants(pkgs)
+	}
+
+	// Errors are reported once, by the load that type-checks
+	if mode&packages.NeedTypes != 0 {
+		for _, pkg := range pkgs {
+			for _, e := range pkg.Errors {
+				log.Printf("type error (%s): %v", pkg.PkgPath, e)
+			}
+		}
 	}
 
-	var lastPkg *types.Package
+	return pkgs, nil
+}
 
-	for pkgName, fileList := range filesByPkg {
-		info := &types.Info{
-			Defs:       make(map[*ast.Ident]types.Object),
-			Uses:       make(map[*ast.Ident]types.Object),
-			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+// patternsByLoadDir returns the patterns loading roots, grouped by the
+// directory of loadDirs each is loaded from: the innermost one holding the
+// package. Test variants are loaded through the package they test.
+func patternsByLoadDir(roots []*packages.Package, loadDirs []string) map[string][]string {
+	patterns := make(map[string][]string)
+	for _, pkg := range roots {
+		pattern := pkg.PkgPath
+		if _, variant, ok := strings.Cut(pkg.ID, " ["); ok {
+			pattern = strings.TrimSuffix(strings.TrimSuffix(variant, "]"), ".test")
 		}
 
-		config := &types.Config{
-			Importer:                 importer,
-			DisableUnusedImportCheck: true,
-			Error: func(err error) {
-				log.Printf("type error (%s): %v", pkgName, err)
-			},
+		loadDir := loadDirs[0]
+		if len(pkg.GoFiles) > 0 {
+			found := false
+			for _, candidate := range loadDirs {
+				rel, err := filepath.Rel(candidate, filepath.Dir(pkg.GoFiles[0]))
+				if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+					continue
+				}
+				if !found || len(candidate) > len(loadDir) {
+					loadDir, found = candidate, true
+				}
+			}
+		}
+		if !slices.Contains(patterns[loadDir], pattern) {
+			patterns[loadDir] = append(patterns[loadDir], pattern)
 		}
+	}
+	return patterns
+}
 
-		pkg, err := config.Check(pkgName, fset, fileList, info)
-		if err != nil {
-			log.Printf("type checking failed for package %s: %v", pkgName, err)
+// testVariants drops the packages that loading tests duplicates: the generated
+// test mains (ID "p.test") and every package that also has a test variant
+// (ID "p [p.test]"), which compiles the same files plus the in-package tests.
+// External test packages (ID "p_test [p.test]") are kept.
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	hasVariant := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if strings.HasPrefix(pkg.ID, pkg.PkgPath+" [") {
+			hasVariant[pkg.PkgPath] = true
+		}
+	}
+
+	var kept []*packages.Package
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test") || (pkg.ID == pkg.PkgPath && hasVariant[pkg.PkgPath]) {
 			continue
 		}
+		kept = append(kept, pkg)
+	}
+	return kept
+}
 
-		lastPkg = pkg
+// PackageFiles flattens the syntax and type information of pkgs into the
+// file map and types.Info consumed by BuildSimplifiedASTs.
+func PackageFiles(fset *token.FileSet, pkgs []*packages.Package) (map[string]*ast.File, *types.Info) {
+	files := make(map[string]*ast.File)
+	mergedInfo := newTypesInfo()
 
-		for k, v := range info.Defs {
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			files[fset.Position(f.Package).Filename] = f
+		}
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for k, v := range pkg.TypesInfo.Defs {
 			mergedInfo.Defs[k] = v
 		}
-		for k, v := range info.Uses {
+		for k, v := range pkg.TypesInfo.Uses {
 			mergedInfo.Uses[k] = v
 		}
-		for k, v := range info.Selections {
+		for k, v := range pkg.TypesInfo.Selections {
 			mergedInfo.Selections[k] = v
 		}
+		for k, v := range pkg.TypesInfo.Scopes {
+			mergedInfo.Scopes[k] = v
+		}
+		for k, v := range pkg.TypesInfo.Instances {
+			mergedInfo.Instances[k] = v
+		}
+	}
+
+	return files, mergedInfo
+}
+
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+		Instances:  make(map[*ast.Ident]types.Instance),
+	}
+}
+
+// LoadTypesInfo type-checks the already parsed files with go/packages. Files
+// are reused as-is so the returned types.Info refers to the same AST nodes.
+// The returned package is the one rooted at absPath, if any.
+func LoadTypesInfo(
+	fset *token.FileSet,
+	files map[string]*ast.File,
+	absPath string,
+) (*types.Info, *types.Package, error) {
+	parsed := make(map[string]*ast.File, len(files))
+	for path, f := range files {
+		if abs, err := filepath.Abs(path); err == nil {
+			parsed[abs] = f
+		}
+	}
+
+	parseFile := func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+		if f, ok := parsed[filename]; ok {
+			return f, nil
+		}
+		return parser.ParseFile(fset, filename, src, parser.AllErrors)
 	}
 
-	if lastPkg == nil {
+	pkgs, err := loadPackages(context.Background(), fset, absPath, parseFile, Options{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, mergedInfo := PackageFiles(fset, pkgs)
+
+	var rootPkg *types.Package
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		if rootPkg == nil || (len(pkg.GoFiles) > 0 && filepath.Dir(pkg.GoFiles[0]) == absPath) {
+			rootPkg = pkg.Types
+		}
+	}
+	if rootPkg == nil {
 		return nil, nil, fmt.Errorf("type checking failed for all packages")
 	}
 
-	return mergedInfo, lastPkg, nil
+	return mergedInfo, rootPkg, nil
+}
+
+// packageOf returns the type-checked package that file belongs to.
+func packageOf(file *ast.File, typesInfo *types.Info) *types.Package {
+	fileScope, ok := typesInfo.Scopes[file]
+	if !ok || fileScope.Parent() == nil {
+		return nil
+	}
+	pkgScope := fileScope.Parent()
+	for _, name := range pkgScope.Names() {
+		if obj := pkgScope.Lookup(name); obj.Pkg() != nil {
+			return obj.Pkg()
+		}
+	}
+	return nil
 }
 
 func buildSimplifiedASTWithGlobals(
 	fset *token.FileSet,
 	node ast.Node,
 	path string,
-	globalVars map[string]struct{},
+	globalVars map[types.Object]struct{},
 	typesInfo *types.Info,
 ) *SimplifiedASTNode {
 	if node == nil {
@@ -147,7 +380,18 @@ func buildSimplifiedASTWithGlobals(
 	case *ast.File:
 		simp = newNode("File", filepath.Base(path), fset, path, n.Pos(), nil)
 		if n.Name != nil {
-			pkgNode := newNode("Package", n.Name.Name, fset, path, n.Name.Pos(), typesInfo.ObjectOf(n.Name))
+			pkgNode := newNode("Package", n.Name.Name, fset, path, n.Name.Pos(), nil)
+			if pkg := packageOf(n, typesInfo); pkg != nil {
+				pkgNode.DeclaredAt = &ModifiedDefinitionInfo{
+					Name:        pkg.Name(),
+					URI:         pkgNode.Position.URI,
+					Line:        pkgNode.Position.Line,
+					Character:   pkgNode.Position.Character,
+					Kind:        "package",
+					PackageName: pkg.Name(),
+					PackagePath: pkg.Path(),
+				}
+			}
 			children = append(children, pkgNode)
 		}
 		for _, decl := range n.Decls {
@@ -161,10 +405,16 @@ func buildSimplifiedASTWithGlobals(
 				specs = append(specs, child)
 			}
 		}
+		if n.Tok == token.CONST {
+			for _, spec := range specs {
+				if spec.Type == "GlobalVar" {
+					spec.Type = "Const"
+				}
+			}
+		}
 		return &SimplifiedASTNode{Children: specs}
 
 	case *ast.FuncDecl:
-		fmt.Println("Processing Function:", n.Name.Name)
 		nodeType := "Function"
 		if n.Recv != nil {
 			nodeType = "Method"
@@ -179,128 +429,26 @@ func buildSimplifiedASTWithGlobals(
 			children = append(children, recvWrapper)
 		}
 
-		if n.Type != nil {
-			if n.Type.Params != nil {
-				paramWrapper := newNode("Params", "", fset, path, n.Type.Params.Pos(), nil)
-				for _, field := range n.Type.Params.List {
-					if param := buildSimplifiedASTWithGlobals(fset, field, path, globalVars, typesInfo); param != nil {
-						paramWrapper.Children = append(paramWrapper.Children, param)
-					}
-				}
-				children = append(children, paramWrapper)
-			}
+		if n.Type != nil && n.Type.TypeParams != nil {
+			children = append(children, newTypeParamsNode(fset, n.Type.TypeParams, path, typesInfo))
+		}
 
-			if n.Type.Results != nil {
-				resultWrapper := newNode("Results", "", fset, path, n.Type.Results.Pos(), nil)
-				for _, field := range n.Type.Results.List {
-					if result := buildSimplifiedASTWithGlobals(fset, field, path, globalVars, typesInfo); result != nil {
-						resultWrapper.Children = append(resultWrapper.Children, result)
-					}
-				}
-				children = append(children, resultWrapper)
-			}
+		if n.Type != nil {
+			children = append(children, newSignatureNodes(fset, n.Type, path, globalVars, typesInfo)...)
 		}
 
 		if n.Body != nil {
-			handled := map[token.Pos]bool{}
-
-			ast.Inspect(n.Body, func(x ast.Node) bool {
-				switch expr := x.(type) {
-
-				case *ast.CallExpr:
-					switch fun := expr.Fun.(type) {
-					case *ast.Ident:
-						obj := typesInfo.ObjectOf(fun)
-						children = append(children, newNode("Call", fun.Name, fset, path, fun.Pos(), obj))
-					case *ast.SelectorExpr:
-						obj := typesInfo.ObjectOf(fun.Sel)
-						children = append(children, newNode("MethodCall", fun.Sel.Name, fset, path, fun.Sel.Pos(), obj))
-						handled[fun.Sel.Pos()] = true
-					}
-
-				case *ast.Ident:
-					obj := typesInfo.ObjectOf(expr)
-					if obj != nil {
-						if _, ok := globalVars[expr.Name]; ok {
-							children = append(children, newNode("GlobalVarUse", expr.Name, fset, path, expr.Pos(), obj))
-						} else if v, ok := obj.(*types.Var); ok && !v.IsField() {
-							children = append(children, newNode("VarUse", expr.Name, fset, path, expr.Pos(), obj))
-						}
-					}
-
-				case *ast.CompositeLit:
-					switch t := expr.Type.(type) {
-					case *ast.SelectorExpr:
-						obj := typesInfo.ObjectOf(t.Sel)
-						children = append(children, newNode("TypeUse", t.Sel.Name, fset, path, t.Sel.Pos(), obj))
-						handled[t.Sel.Pos()] = true
-					case *ast.Ident:
-						obj := typesInfo.ObjectOf(t)
-						children = append(children, newNode("TypeUse", t.Name, fset, path, t.Pos(), obj))
-						handled[t.Pos()] = true
-					}
-
-				case *ast.SelectorExpr:
-					if handled[expr.Sel.Pos()] {
-						return false
-					}
-
-					if selInfo, ok := typesInfo.Selections[expr]; ok {
-						obj := selInfo.Obj()
-						kind := "FieldUse"
-						if selInfo.Kind() == types.MethodVal || selInfo.Kind() == types.MethodExpr {
-							kind = "MethodCall"
-						}
-
-						node := newResolvedNode(kind, expr.Sel.Name, fset, path, expr.Sel.Pos(), obj)
-
-						children = append(children, node)
-					} else {
-						// fallback to ObjectOf if Selection failed
-						obj := typesInfo.ObjectOf(expr.Sel)
-
-						kind := "FieldUse"
-						if obj != nil {
-							switch v := obj.(type) {
-							case *types.TypeName:
-								kind = "TypeUse"
-							case *types.Var:
-								if v.IsField() {
-									kind = "FieldUse"
-								} else if v.Pkg() != nil {
-									kind = "GlobalVarUse"
-								} else {
-									kind = "VarUse"
-								}
-							case *types.Func:
-								kind = "MethodCall"
-							}
-						}
-
-						node := newResolvedNode(kind, expr.Sel.Name, fset, path, expr.Sel.Pos(), obj)
-						if node.DeclaredAt != nil {
-							log.Printf("  -> DeclaredAt: %+v\n", *node.DeclaredAt)
-						} else {
-							log.Println("  -> DeclaredAt: nil")
-						}
-
-						children = append(children, node)
-					}
-
-					handled[expr.Sel.Pos()] = true
-					return false
-				}
-				return true
-			})
+			children = append(children, buildBodyNodes(fset, n.Body, path, globalVars, typesInfo, n.Name.Name)...)
 		}
 
 	case *ast.TypeSpec:
 		if n.Assign != token.NoPos {
-			fmt.Println("Skipping alias:", n.Name.Name)
 			return nil
 		}
-		fmt.Println("Processing TypeSpec:", n.Name.Name)
 		obj := typesInfo.ObjectOf(n.Name)
+		if n.TypeParams != nil {
+			children = append(children, newTypeParamsNode(fset, n.TypeParams, path, typesInfo))
+		}
 		switch actual := n.Type.(type) {
 		case *ast.StructType:
 			simp = newNode("Struct", n.Name.Name, fset, path, n.Pos(), obj)
@@ -314,19 +462,25 @@ func buildSimplifiedASTWithGlobals(
 		}
 
 	case *ast.StructType:
-		fmt.Println("Processing StructType")
 		simp = newNode("Struct", "", fset, path, n.Pos(), nil)
 		if n.Fields != nil {
 			for _, field := range n.Fields.List {
+				if embedded := newEmbeddedNode(fset, field, path, typesInfo); embedded != nil {
+					children = append(children, embedded)
+					continue
+				}
 				addChild(field)
 			}
 		}
 
 	case *ast.InterfaceType:
-		fmt.Println("Processing InterfaceType")
 		simp = newNode("Interface", "", fset, path, n.Pos(), nil)
 		if n.Methods != nil {
 			for _, field := range n.Methods.List {
+				if embedded := newEmbeddedNode(fset, field, path, typesInfo); embedded != nil {
+					children = append(children, embedded)
+					continue
+				}
 				addChild(field)
 			}
 		}
@@ -364,13 +518,23 @@ func buildSimplifiedASTWithGlobals(
 		}
 
 	case *ast.ValueSpec:
-		fmt.Println("Processing GlobalVar")
 		simp = newNode("GlobalVar", "", fset, path, n.Pos(), nil)
 		for _, name := range n.Names {
 			addChild(name)
-			globalVars[name.Name] = struct{}{}
 		}
-		addChild(n.Type)
+		// The type is kept below a "ValueType" node named after the type as
+		// written, so that it is not mistaken for one of the names
+		if n.Type != nil {
+			var typeObj types.Object
+			if obj := typeNameOf(n.Type, typesInfo); obj != nil {
+				typeObj = obj
+			}
+			valueType := newNode("ValueType", types.ExprString(n.Type), fset, path, n.Type.Pos(), typeObj)
+			if child := buildSimplifiedASTWithGlobals(fset, n.Type, path, globalVars, typesInfo); child != nil {
+				valueType.Children = []*SimplifiedASTNode{child}
+			}
+			children = append(children, valueType)
+		}
 
 	case *ast.Ident:
 		simp = newNode("Ident", n.Name, fset, path, n.Pos(), typesInfo.ObjectOf(n))
@@ -392,32 +556,135 @@ func buildSimplifiedASTWithGlobals(
 	return simp
 }
 
+// newEmbeddedNode returns an "Embedded" node for a struct field or interface
+// element that embeds a named type, or nil when field is anything else. The
+// node is named after the embedded type as written, e.g. "*Base" or
+// "io.Reader", and DeclaredAt points at the embedded type's declaration.
+func newEmbeddedNode(fset *token.FileSet, field *ast.Field, path string, typesInfo *types.Info) *SimplifiedASTNode {
+	if len(field.Names) > 0 {
+		return nil
+	}
+
+	obj := typeNameOf(field.Type, typesInfo)
+	if obj == nil {
+		// Unions and approximation elements of constraint interfaces
+		return nil
+	}
+	return newNode("Embedded", types.ExprString(field.Type), fset, path, field.Type.Pos(), obj)
+}
+
+// newTypeParamsNode returns a "TypeParams" node holding a "TypeParam" node for
+// every type parameter declared in list. Each of them has a "Constraint" child
+// named after the constraint as written, which points at the constraint's
+// declaration when it is a named type.
+func newTypeParamsNode(fset *token.FileSet, list *ast.FieldList, path string, typesInfo *types.Info) *SimplifiedASTNode {
+	wrapper := newNode("TypeParams", "", fset, path, list.Pos(), nil)
+	for _, field := range list.List {
+		var constraintObj types.Object
+		if obj := typeNameOf(field.Type, typesInfo); obj != nil {
+			constraintObj = obj
+		}
+		for _, name := range field.Names {
+			param := newNode("TypeParam", name.Name, fset, path, name.Pos(), typesInfo.ObjectOf(name))
+			param.Children = []*SimplifiedASTNode{
+				newNode("Constraint", types.ExprString(field.Type), fset, path, field.Type.Pos(), constraintObj),
+			}
+			wrapper.Children = append(wrapper.Children, param)
+		}
+	}
+	return wrapper
+}
+
+// typeNameOf returns the named type a type expression refers to, looking
+// through pointers and type arguments, or nil for any other expression.
+func typeNameOf(expr ast.Expr, typesInfo *types.Info) *types.TypeName {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.IndexExpr:
+		expr = x.X
+	case *ast.IndexListExpr:
+		expr = x.X
+	}
+
+	var ident *ast.Ident
+	switch x := expr.(type) {
+	case *ast.Ident:
+		ident = x
+	case *ast.SelectorExpr:
+		ident = x.Sel
+	default:
+		return nil
+	}
+
+	obj, _ := typesInfo.Uses[ident].(*types.TypeName)
+	return obj
+}
+
 func BuildSimplifiedASTs(
 	fset *token.FileSet,
 	files map[string]*ast.File,
 	typesInfo *types.Info,
 ) map[string]*SimplifiedASTNode {
-	asts := make(map[string]*SimplifiedASTNode)
-	globalVars := make(map[string]struct{})
+	asts, _ := buildSimplifiedASTs(context.Background(), fset, files, typesInfo, nil, Options{}.workers())
+	return asts
+}
 
-	// First pass: collect all global variable names from all files
+// buildSimplifiedASTs is BuildSimplifiedASTs with files simplified on up to
+// workers goroutines until ctx is done. Files with an entry in cached reuse
+// it instead.
+func buildSimplifiedASTs(
+	ctx context.Context,
+	fset *token.FileSet,
+	files map[string]*ast.File,
+	typesInfo *types.Info,
+	cached map[string]*SimplifiedASTNode,
+	workers int,
+) (map[string]*SimplifiedASTNode, error) {
+	globalVars := make(map[types.Object]struct{})
+
+	// First pass: collect the package-level variables of all files. They are
+	// keyed by object so that same-named globals of different packages stay apart.
 	for _, file := range files {
-		ast.Inspect(file, func(n ast.Node) bool {
-			if vspec, ok := n.(*ast.ValueSpec); ok {
-				for _, name := range vspec.Names {
-					globalVars[name.Name] = struct{}{}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if vspec, ok := spec.(*ast.ValueSpec); ok {
+					for _, name := range vspec.Names {
+						if obj := typesInfo.ObjectOf(name); obj != nil {
+							globalVars[obj] = struct{}{}
+						}
+					}
 				}
 			}
-			return true
-		})
+		}
 	}
 
-	// Second pass: generate simplified ASTs using the collected global variables
-	for path, file := range files {
-		asts[path] = buildSimplifiedASTWithGlobals(fset, file, path, globalVars, typesInfo)
+	// Second pass: generate simplified ASTs using the collected global
+	// variables, which like typesInfo are only read from here on
+	paths := sortedKeys(files)
+	roots := make([]*SimplifiedASTNode, len(paths))
+	err := parallel(ctx, workers, len(paths), func(i int) error {
+		if root, ok := cached[paths[i]]; ok {
+			roots[i] = root
+			return nil
+		}
+		roots[i] = buildSimplifiedASTWithGlobals(fset, files[paths[i]], paths[i], globalVars, typesInfo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return asts
+	asts := make(map[string]*SimplifiedASTNode, len(paths))
+	for i, path := range paths {
+		asts[path] = roots[i]
+	}
+	return asts, nil
 }
 
 func newNode(kind, name string, fset *token.FileSet, path string, pos token.Pos, obj types.Object) *SimplifiedASTNode {
@@ -427,22 +694,24 @@ func newNode(kind, name string, fset *token.FileSet, path string, pos token.Pos,
 	var declaredAt *ModifiedDefinitionInfo
 	if obj != nil {
 		objPos := fset.Position(obj.Pos())
-		objAbsPath, _ := filepath.Abs(objPos.Filename)
 
-		pkgName := ""
+		pkgName, pkgPath := "", ""
 		if obj.Pkg() != nil {
 			pkgName = obj.Pkg().Name()
+			pkgPath = obj.Pkg().Path()
 		}
 
 		declaredAt = &ModifiedDefinitionInfo{
 			Name:         obj.Name(),
-			URI:          "file://" + filepath.ToSlash(objAbsPath),
+			URI:          fileURI(objPos.Filename),
 			Line:         objPos.Line - 1,
 			Character:    objPos.Column - 1,
 			Kind:         strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", obj), "*types.")),
 			Type:         obj.Type().String(),
 			ReceiverType: receiverTypeString(obj),
 			PackageName:  pkgName,
+			PackagePath:  pkgPath,
+			Value:        constValue(obj),
 		}
 	}
 
@@ -458,6 +727,19 @@ func newNode(kind, name string, fset *token.FileSet, path string, pos token.Pos,
 	}
 }
 
+// fileURI turns a declaration's file name into a file:// URI. Objects of the
+// universe scope (builtin types and functions) have no file and get "".
+func fileURI(filename string) string {
+	if filename == "" {
+		return ""
+	}
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		absPath = filename
+	}
+	return "file://" + filepath.ToSlash(absPath)
+}
+
 func receiverTypeString(obj types.Object) string {
 	if fn, ok := obj.(*types.Func); ok {
 		if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
@@ -472,30 +754,415 @@ func newResolvedNode(kind, name string, fset *token.FileSet, path string, pos to
 	if obj != nil {
 		position := fset.Position(obj.Pos())
 
-		absPath, err := filepath.Abs(position.Filename)
-		if err != nil {
-			absPath = position.Filename
-		}
-
-		pkgName := ""
+		pkgName, pkgPath := "", ""
 		if obj.Pkg() != nil {
 			pkgName = obj.Pkg().Name()
+			pkgPath = obj.Pkg().Path()
 		}
 
 		node.DeclaredAt = &ModifiedDefinitionInfo{
 			Name:         obj.Name(),
-			URI:          "file://" + filepath.ToSlash(absPath),
-			Line:         position.Line,
-			Character:    position.Column,
+			URI:          fileURI(position.Filename),
+			Line:         position.Line - 1,
+			Character:    position.Column - 1,
 			Kind:         objectKind(obj),
 			Type:         obj.Type().String(),
 			ReceiverType: receiverType(obj),
 			PackageName:  pkgName,
+			PackagePath:  pkgPath,
+			Value:        constValue(obj),
+		}
+	}
+	return node
+}
+
+// buildBodyNodes flattens the calls, uses and concurrency operations of a
+// function body. Function literals become FuncLit nodes of their own, named
+// after owner and numbered in source order.
+func buildBodyNodes(
+	fset *token.FileSet,
+	body *ast.BlockStmt,
+	path string,
+	globalVars map[types.Object]struct{},
+	typesInfo *types.Info,
+	owner string,
+) []*SimplifiedASTNode {
+	var children []*SimplifiedASTNode
+	handled := map[token.Pos]bool{}
+	access := accessModes(body)
+	selectCases := map[ast.Node]bool{}
+	spawned := map[*ast.FuncLit]bool{}
+	literals := 0
+	newLiteral := func(lit *ast.FuncLit) *SimplifiedASTNode {
+		literals++
+		name := fmt.Sprintf("%s.func%d", owner, literals)
+		return newFuncLitNode(fset, lit, path, globalVars, typesInfo, name)
+	}
+
+	ast.Inspect(body, func(x ast.Node) bool {
+		switch expr := x.(type) {
+
+		case *ast.CallExpr:
+			// Explicitly instantiated generic calls, e.g. Map[int](xs)
+			callee := expr.Fun
+			switch fun := callee.(type) {
+			case *ast.IndexExpr:
+				callee = fun.X
+			case *ast.IndexListExpr:
+				callee = fun.X
+			}
+
+			switch fun := callee.(type) {
+			case *ast.Ident:
+				obj := typesInfo.ObjectOf(fun)
+				children = append(children, newNode("Call", fun.Name, fset, path, fun.Pos(), obj))
+				if _, ok := obj.(*types.Builtin); ok && fun.Name == "close" && len(expr.Args) == 1 {
+					children = append(children, newChannelNode("Close", expr.Args[0], fset, path, expr.Pos(), typesInfo))
+				}
+			case *ast.SelectorExpr:
+				obj := typesInfo.ObjectOf(fun.Sel)
+				children = append(children, newNode("MethodCall", fun.Sel.Name, fset, path, fun.Sel.Pos(), obj))
+				handled[fun.Sel.Pos()] = true
+			}
+
+		case *ast.GoStmt:
+			if lit, ok := expr.Call.Fun.(*ast.FuncLit); ok {
+				litNode := newLiteral(lit)
+				goNode := newNode("Go", litNode.Name, fset, path, expr.Pos(), nil)
+				goNode.Children = []*SimplifiedASTNode{litNode}
+				children = append(children, goNode)
+				spawned[lit] = true
+			} else {
+				children = append(children, newGoNode(fset, expr, path, typesInfo))
+			}
+
+		case *ast.FuncLit:
+			if !spawned[expr] {
+				children = append(children, newLiteral(expr))
+			}
+			return false
+
+		case *ast.SelectStmt:
+			selectNode := newNode("Select", "", fset, path, expr.Pos(), nil)
+			for _, clause := range expr.Body.List {
+				comm, ok := clause.(*ast.CommClause)
+				if !ok || comm.Comm == nil {
+					continue
+				}
+				if send, ok := comm.Comm.(*ast.SendStmt); ok {
+					selectNode.Children = append(selectNode.Children, newChannelNode("Send", send.Chan, fset, path, send.Arrow, typesInfo))
+					selectCases[send] = true
+				} else if recv := receiveExpr(comm.Comm); recv != nil {
+					selectNode.Children = append(selectNode.Children, newChannelNode("Receive", recv.X, fset, path, recv.OpPos, typesInfo))
+					selectCases[recv] = true
+				}
+			}
+			children = append(children, selectNode)
+
+		case *ast.SendStmt:
+			if !selectCases[expr] {
+				children = append(children, newChannelNode("Send", expr.Chan, fset, path, expr.Arrow, typesInfo))
+			}
+
+		case *ast.UnaryExpr:
+			if expr.Op == token.ARROW && !selectCases[expr] {
+				children = append(children, newChannelNode("Receive", expr.X, fset, path, expr.OpPos, typesInfo))
+			}
+
+		case *ast.RangeStmt:
+			if t := typesInfo.TypeOf(expr.X); t != nil {
+				if _, ok := t.Underlying().(*types.Chan); ok {
+					children = append(children, newChannelNode("Receive", expr.X, fset, path, expr.For, typesInfo))
+				}
+			}
+
+		case *ast.Ident:
+			if handled[expr.Pos()] {
+				return true
+			}
+			obj := typesInfo.ObjectOf(expr)
+			if obj != nil {
+				if c, ok := obj.(*types.Const); ok {
+					// Only package-level constants are declarations of their own
+					if c.Pkg() != nil && c.Parent() == c.Pkg().Scope() {
+						children = append(children, newNode("ConstUse", expr.Name, fset, path, expr.Pos(), obj))
+					}
+				} else if _, ok := globalVars[obj]; ok {
+					node := newNode("GlobalVarUse", expr.Name, fset, path, expr.Pos(), obj)
+					node.Access = access[expr.Pos()]
+					children = append(children, node)
+				} else if v, ok := obj.(*types.Var); ok && !v.IsField() {
+					node := newNode("VarUse", expr.Name, fset, path, expr.Pos(), obj)
+					node.Access = access[expr.Pos()]
+					children = append(children, node)
+				}
+			}
+
+		case *ast.CompositeLit:
+			switch t := expr.Type.(type) {
+			case *ast.SelectorExpr:
+				obj := typesInfo.ObjectOf(t.Sel)
+				children = append(children, newNode("TypeUse", t.Sel.Name, fset, path, t.Sel.Pos(), obj))
+				handled[t.Sel.Pos()] = true
+			case *ast.Ident:
+				obj := typesInfo.ObjectOf(t)
+				children = append(children, newNode("TypeUse", t.Name, fset, path, t.Pos(), obj))
+				handled[t.Pos()] = true
+			}
+
+		case *ast.SelectorExpr:
+			if handled[expr.Sel.Pos()] {
+				return true
+			}
+
+			if selInfo, ok := typesInfo.Selections[expr]; ok {
+				obj := selInfo.Obj()
+				kind := "FieldUse"
+				if selInfo.Kind() == types.MethodVal || selInfo.Kind() == types.MethodExpr {
+					kind = "MethodCall"
+				}
+
+				node := newResolvedNode(kind, expr.Sel.Name, fset, path, expr.Sel.Pos(), obj)
+				if kind == "FieldUse" {
+					node.Access = access[expr.Sel.Pos()]
+				}
+
+				children = append(children, node)
+			} else {
+				// fallback to ObjectOf if Selection failed
+				obj := typesInfo.ObjectOf(expr.Sel)
+
+				kind := "FieldUse"
+				if obj != nil {
+					switch v := obj.(type) {
+					case *types.TypeName:
+						kind = "TypeUse"
+					case *types.Const:
+						kind = "ConstUse"
+					case *types.Var:
+						if v.IsField() {
+							kind = "FieldUse"
+						} else if v.Pkg() != nil {
+							kind = "GlobalVarUse"
+						} else {
+							kind = "VarUse"
+						}
+					case *types.Func:
+						kind = "MethodCall"
+					}
+				}
+
+				node := newResolvedNode(kind, expr.Sel.Name, fset, path, expr.Sel.Pos(), obj)
+				if kind == "FieldUse" || kind == "GlobalVarUse" || kind == "VarUse" {
+					node.Access = access[expr.Sel.Pos()]
+				}
+
+				children = append(children, node)
+			}
+
+			// Keep going into the operand, which may itself be a
+			// variable or field use, e.g. cfg in cfg.Name
+			handled[expr.Sel.Pos()] = true
+			return true
+		}
+		return true
+	})
+	return children
+}
+
+// newGoNode records a go statement calling a named function or method, which
+// is its DeclaredAt. Goroutines running a function literal are recorded by
+// buildBodyNodes with the FuncLit node as their child.
+func newGoNode(fset *token.FileSet, stmt *ast.GoStmt, path string, info *types.Info) *SimplifiedASTNode {
+	callee := stmt.Call.Fun
+	switch fun := callee.(type) {
+	case *ast.IndexExpr:
+		callee = fun.X
+	case *ast.IndexListExpr:
+		callee = fun.X
+	}
+
+	switch fun := callee.(type) {
+	case *ast.Ident:
+		return newNode("Go", fun.Name, fset, path, stmt.Pos(), info.ObjectOf(fun))
+	case *ast.SelectorExpr:
+		return newNode("Go", fun.Sel.Name, fset, path, stmt.Pos(), info.ObjectOf(fun.Sel))
+	default:
+		return newNode("Go", types.ExprString(callee), fset, path, stmt.Pos(), nil)
+	}
+}
+
+// newSignatureNodes returns the Params and Results nodes of a function type.
+func newSignatureNodes(
+	fset *token.FileSet,
+	typ *ast.FuncType,
+	path string,
+	globalVars map[types.Object]struct{},
+	typesInfo *types.Info,
+) []*SimplifiedASTNode {
+	var nodes []*SimplifiedASTNode
+	if typ.Params != nil {
+		paramWrapper := newNode("Params", "", fset, path, typ.Params.Pos(), nil)
+		for _, field := range typ.Params.List {
+			if param := buildSimplifiedASTWithGlobals(fset, field, path, globalVars, typesInfo); param != nil {
+				paramWrapper.Children = append(paramWrapper.Children, param)
+			}
 		}
+		nodes = append(nodes, paramWrapper)
 	}
+
+	if typ.Results != nil {
+		resultWrapper := newNode("Results", "", fset, path, typ.Results.Pos(), nil)
+		for _, field := range typ.Results.List {
+			if result := buildSimplifiedASTWithGlobals(fset, field, path, globalVars, typesInfo); result != nil {
+				resultWrapper.Children = append(resultWrapper.Children, result)
+			}
+		}
+		nodes = append(nodes, resultWrapper)
+	}
+	return nodes
+}
+
+// newFuncLitNode builds the node of a function literal: its signature, a
+// Capture node for every variable of an enclosing function it refers to, and
+// the nodes of its body.
+func newFuncLitNode(
+	fset *token.FileSet,
+	lit *ast.FuncLit,
+	path string,
+	globalVars map[types.Object]struct{},
+	typesInfo *types.Info,
+	name string,
+) *SimplifiedASTNode {
+	node := newNode("FuncLit", name, fset, path, lit.Pos(), nil)
+	node.Children = newSignatureNodes(fset, lit.Type, path, globalVars, typesInfo)
+
+	captured := map[types.Object]bool{}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := typesInfo.Uses[ident].(*types.Var)
+		if !ok || v.IsField() || v.Pkg() == nil || v.Parent() == v.Pkg().Scope() {
+			return true
+		}
+		if v.Pos() >= lit.Pos() && v.Pos() < lit.End() || captured[v] {
+			return true
+		}
+		captured[v] = true
+		node.Children = append(node.Children, newNode("Capture", ident.Name, fset, path, ident.Pos(), v))
+		return true
+	})
+
+	node.Children = append(node.Children, buildBodyNodes(fset, lit.Body, path, globalVars, typesInfo, name)...)
 	return node
 }
 
+// newChannelNode records a channel operation of the given kind at pos. Its
+// DeclaredAt is the variable or field holding the channel, when there is one.
+func newChannelNode(kind string, ch ast.Expr, fset *token.FileSet, path string, pos token.Pos, info *types.Info) *SimplifiedASTNode {
+	var obj types.Object
+	for expr := ch; expr != nil && obj == nil; {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			obj = info.ObjectOf(e)
+		case *ast.SelectorExpr:
+			obj = info.ObjectOf(e.Sel)
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			expr = nil
+		}
+	}
+	if _, ok := obj.(*types.Var); !ok {
+		obj = nil
+	}
+	return newNode(kind, types.ExprString(ch), fset, path, pos, obj)
+}
+
+// receiveExpr returns the receive operation of a select case, which is either
+// a bare <-ch or the right-hand side of an assignment.
+func receiveExpr(stmt ast.Stmt) *ast.UnaryExpr {
+	var expr ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		expr = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			expr = s.Rhs[0]
+		}
+	}
+	if unary, ok := ast.Unparen(expr).(*ast.UnaryExpr); ok && unary.Op == token.ARROW {
+		return unary
+	}
+	return nil
+}
+
+// accessModes finds the identifiers in body that are written to or have
+// their address taken, keyed by position. Every identifier along a selector,
+// index or dereference chain counts, so assigning to g.field[i] writes g.
+func accessModes(body ast.Node) map[token.Pos]string {
+	access := make(map[token.Pos]string)
+	mark := func(expr ast.Expr, mode string) {
+		for expr != nil {
+			switch e := expr.(type) {
+			case *ast.Ident:
+				access[e.Pos()] = mode
+				return
+			case *ast.SelectorExpr:
+				access[e.Sel.Pos()] = mode
+				expr = e.X
+			case *ast.IndexExpr:
+				expr = e.X
+			case *ast.StarExpr:
+				expr = e.X
+			case *ast.ParenExpr:
+				expr = e.X
+			default:
+				return
+			}
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			if stmt.Tok == token.DEFINE {
+				return true
+			}
+			for _, lhs := range stmt.Lhs {
+				mark(lhs, "write")
+			}
+		case *ast.IncDecStmt:
+			mark(stmt.X, "write")
+		case *ast.RangeStmt:
+			if stmt.Tok == token.ASSIGN {
+				mark(stmt.Key, "write")
+				mark(stmt.Value, "write")
+			}
+		case *ast.UnaryExpr:
+			if stmt.Op == token.AND {
+				mark(stmt.X, "addressTaken")
+			}
+		}
+		return true
+	})
+	return access
+}
+
+// constValue returns the exact value of a constant, or "" for other objects.
+func constValue(obj types.Object) string {
+	if c, ok := obj.(*types.Const); ok {
+		return c.Val().ExactString()
+	}
+	return ""
+}
+
 func objectKind(obj types.Object) string {
 	switch obj := obj.(type) {
 	case *types.Const:
@@ -580,11 +1247,24 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 
 	var walk func(node *SimplifiedASTNode, parentType string)
 
-	packageName := ""
+	packageName, packagePath := "", ""
 	if ast != nil && len(ast.Children) > 0 && ast.Children[0].Type == "Package" {
 		packageName = ast.Children[0].Name
+		if ast.Children[0].DeclaredAt != nil {
+			packagePath = ast.Children[0].DeclaredAt.PackagePath
+		}
 	}
 
+	// Names of the enclosing type and operation, used to qualify the fields,
+	// interface methods and parameters they declare.
+	var currentType, currentOperation string
+	// The top-level function or method whose body is being walked, which
+	// qualifies the local variables captured by its function literals
+	var declaringOperation string
+	// The generic function or type whose type parameters are being walked
+	var typeParamOwner string
+	initCount := 0
+
 	walk = func(node *SimplifiedASTNode, parentType string) {
 		if node == nil || node.Position == nil {
 			return
@@ -624,13 +1304,32 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 				}
 			}
 
+			// The type checker knows pointer and generic receivers the
+			// simplified receiver field does not spell out
+			if node.DeclaredAt != nil && node.DeclaredAt.ReceiverType != "" {
+				receiverType = baseTypeName(node.DeclaredAt.ReceiverType)
+			}
+
+			name := node.Name
+			if node.Type == "Function" && name == "init" {
+				// A package may declare any number of init functions
+				initCount++
+				name = fmt.Sprintf("init#%s.%d", path.Base(node.Position.URI), initCount)
+			}
+
+			currentOperation = qualify(packagePath, receiverType, name)
+			declaringOperation = currentOperation
+			typeParamOwner = currentOperation
 			symbols[posKey] = &ModifiedDefinitionInfo{
-				Name:         node.Name,
-				Kind:         kind,
-				URI:          node.Position.URI,
-				Line:         node.Position.Line,
-				Character:    node.Position.Character,
-				ReceiverType: receiverType,
+				Name:          node.Name,
+				Kind:          kind,
+				URI:           node.Position.URI,
+				Line:          node.Position.Line,
+				Character:     node.Position.Character,
+				ReceiverType:  receiverType,
+				PackageName:   packageName,
+				PackagePath:   packagePath,
+				QualifiedName: currentOperation,
 			}
 
 		case "Params":
@@ -657,39 +1356,120 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 					}
 
 					for _, ident := range paramNames {
+						// Prefer the type-checked type, it is qualified by import path
 						t := paramType
-						if t == "" && ident.DeclaredAt != nil {
+						if ident.DeclaredAt != nil && ident.DeclaredAt.Type != "" {
 							t = ident.DeclaredAt.Type
 						}
 						identKey := fmt.Sprintf("%s:%d:%d", ident.Position.URI, ident.Position.Line, ident.Position.Character)
 						symbols[identKey] = &ModifiedDefinitionInfo{
-							Name:      ident.Name,
-							Kind:      "param",
-							Type:      t,
-							URI:       ident.Position.URI,
-							Line:      ident.Position.Line,
-							Character: ident.Position.Character,
+							Name:          ident.Name,
+							Kind:          "param",
+							Type:          t,
+							URI:           ident.Position.URI,
+							Line:          ident.Position.Line,
+							Character:     ident.Position.Character,
+							PackageName:   packageName,
+							PackagePath:   packagePath,
+							QualifiedName: qualify(currentOperation, ident.Name),
 						}
 					}
 				}
 			}
 
-		case "GlobalVar":
+		case "GlobalVar", "Const":
+			kind := "var"
+			if node.Type == "Const" {
+				kind = "const"
+			}
 			for _, child := range node.Children {
 				if child.Type == "Ident" {
 					childKey := fmt.Sprintf("%s:%d:%d", child.Position.URI, child.Position.Line, child.Position.Character)
 					symbols[childKey] = &ModifiedDefinitionInfo{
-						Name:      child.Name,
-						Kind:      "var",
-						URI:       child.Position.URI,
-						Line:      child.Position.Line,
-						Character: child.Position.Character,
+						Name:          child.Name,
+						Kind:          kind,
+						URI:           child.Position.URI,
+						Line:          child.Position.Line,
+						Character:     child.Position.Character,
+						PackageName:   packageName,
+						PackagePath:   packagePath,
+						QualifiedName: qualify(packagePath, child.Name),
 					}
+					if child.DeclaredAt != nil {
+						symbols[childKey].Type = child.DeclaredAt.Type
+						symbols[childKey].Value = child.DeclaredAt.Value
+					}
+				}
+			}
+
+		case "FuncLit":
+			// node.Name is owner.funcN; qualify the ordinal by the enclosing
+			// operation so literals of different init functions stay apart
+			enclosing := currentOperation
+			currentOperation = qualify(enclosing, node.Name[strings.LastIndex(node.Name, ".")+1:])
+			symbols[posKey] = &ModifiedDefinitionInfo{
+				Name:          node.Name,
+				Kind:          "funclit",
+				URI:           node.Position.URI,
+				Line:          node.Position.Line,
+				Character:     node.Position.Character,
+				PackageName:   packageName,
+				PackagePath:   packagePath,
+				QualifiedName: currentOperation,
+			}
+			for _, child := range node.Children {
+				walk(child, node.Type)
+			}
+			currentOperation = enclosing
+			return
+
+		case "Capture":
+			// Captured parameters already have a symbol; captured locals get
+			// one so that the closure has something to point at
+			decl := node.DeclaredAt
+			if decl == nil {
+				break
+			}
+			declKey := fmt.Sprintf("%s:%d:%d", decl.URI, decl.Line, decl.Character)
+			if _, ok := symbols[declKey]; !ok {
+				symbols[declKey] = &ModifiedDefinitionInfo{
+					Name:          decl.Name,
+					Kind:          "local",
+					Type:          decl.Type,
+					URI:           decl.URI,
+					Line:          decl.Line,
+					Character:     decl.Character,
+					PackageName:   packageName,
+					PackagePath:   packagePath,
+					QualifiedName: qualify(declaringOperation, decl.Name),
 				}
 			}
 
+		case "TypeParam":
+			symbols[posKey] = &ModifiedDefinitionInfo{
+				Name:          node.Name,
+				Kind:          "typeparam",
+				URI:           node.Position.URI,
+				Line:          node.Position.Line,
+				Character:     node.Position.Character,
+				PackageName:   packageName,
+				PackagePath:   packagePath,
+				QualifiedName: typeParamQualifiedName(typeParamOwner, node.Name),
+			}
+			if len(node.Children) > 0 && node.Children[0].Type == "Constraint" {
+				symbols[posKey].Type = node.Children[0].Name
+			}
+
 		case "Struct":
+			// The fields of a named struct hang off the unnamed struct below
+			// it; those of an anonymous struct type, as in
+			// var cfg struct{ A int }, belong to no named type
+			if node.Name == "" && parentType != node.Type {
+				currentType = ""
+			}
 			if node.Name != "" {
+				currentType = qualify(packagePath, node.Name)
+				typeParamOwner = currentType
 				symbols[posKey] = &ModifiedDefinitionInfo{
 					Name:      node.Name,
 					Kind:      "struct",
@@ -697,16 +1477,23 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 					Line:      node.Position.Line,
 					Character: node.Position.Character,
 					PackageName:  packageName,
+					PackagePath:  packagePath,
+					QualifiedName: currentType,
 				}
 			}
 			for _, field := range node.Children {
 				if field.Type == "Field" {
-					processField(field, "field", symbols)
+					processField(field, "field", currentType, packageName, packagePath, symbols)
 				}
 			}
 
 		case "Interface":
+			if node.Name == "" && parentType != node.Type {
+				currentType = ""
+			}
 			if node.Name != "" {
+				currentType = qualify(packagePath, node.Name)
+				typeParamOwner = currentType
 				symbols[posKey] = &ModifiedDefinitionInfo{
 					Name:      node.Name,
 					Kind:      "interface",
@@ -714,16 +1501,20 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 					Line:      node.Position.Line,
 					Character: node.Position.Character,
 					PackageName:  packageName,
+					PackagePath:  packagePath,
+					QualifiedName: currentType,
 				}
 			}
 			for _, method := range node.Children {
 				if method.Type == "Field" {
-					processField(method, "method", symbols)
+					processField(method, "method", currentType, packageName, packagePath, symbols)
 				}
 			}
 
 		case "Type":
 			if node.Name != "" {
+				currentType = qualify(packagePath, node.Name)
+				typeParamOwner = currentType
 				symbols[posKey] = &ModifiedDefinitionInfo{
 					Name:      node.Name,
 					Kind:      "type",
@@ -731,6 +1522,8 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 					Line:      node.Position.Line,
 					Character: node.Position.Character,
 					PackageName:  packageName,
+					PackagePath:  packagePath,
+					QualifiedName: currentType,
 				}
 			}
 		}
@@ -744,7 +1537,21 @@ func CollectSymbolTable(ast *SimplifiedASTNode) map[string]*ModifiedDefinitionIn
 	return symbols
 }
 
-func processField(field *SimplifiedASTNode, kind string, symbols map[string]*ModifiedDefinitionInfo) {
+// typeParamQualifiedName names a type parameter after its generic function or
+// type, e.g. "example.com/coll.Map[T]", so it cannot clash with a field or
+// parameter of the same name.
+func typeParamQualifiedName(owner, name string) string {
+	if owner == "" {
+		return ""
+	}
+	return owner + "[" + name + "]"
+}
+
+func processField(
+	field *SimplifiedASTNode,
+	kind, owner, packageName, packagePath string,
+	symbols map[string]*ModifiedDefinitionInfo,
+) {
 	var paramType string
 	seenName := false
 
@@ -755,12 +1562,18 @@ func processField(field *SimplifiedASTNode, kind string, symbols map[string]*Mod
 				seenName = true
 				nameKey := fmt.Sprintf("%s:%d:%d", child.Position.URI, child.Position.Line, child.Position.Character)
 				symbols[nameKey] = &ModifiedDefinitionInfo{
-					Name:      child.Name,
-					Kind:      kind,
-					URI:       child.Position.URI,
-					Line:      child.Position.Line,
-					Character: child.Position.Character,
-					Type:      "", // type added later if found
+					Name:          child.Name,
+					Kind:          kind,
+					URI:           child.Position.URI,
+					Line:          child.Position.Line,
+					Character:     child.Position.Character,
+					Type:          "", // type added later if found
+					PackageName:   packageName,
+					PackagePath:   packagePath,
+					QualifiedName: qualify(owner, child.Name),
+				}
+				if child.DeclaredAt != nil {
+					symbols[nameKey].Type = child.DeclaredAt.Type
 				}
 			} else {
 				// Second ident is type
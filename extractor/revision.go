@@ -0,0 +1,70 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractRevision builds the knowledge graph of the Go project at dir as it
+// was at the git revision rev. The revision is checked out into a temporary
+// worktree, so the working copy of dir is left untouched. dir may be any
+// directory inside the repository; the same directory is extracted from the
+// worktree.
+func ExtractRevision(ctx context.Context, dir, rev string, opts Options) (*Graph, error) {
+	absPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	top, err := git(ctx, absPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	// git reports the top level with symlinks resolved
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+	rel, err := filepath.Rel(top, absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate %s inside repository %s: %w", absPath, top, err)
+	}
+
+	tmp, err := os.MkdirTemp("", "gophers-worktree-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	worktree := filepath.Join(tmp, "src")
+	if _, err := git(ctx, top, "worktree", "add", "--detach", worktree, rev); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Use a fresh context so the worktree is unregistered even on cancellation
+		if _, err := git(context.Background(), top, "worktree", "remove", "--force", worktree); err != nil {
+			log.Printf("failed to remove worktree %s: %v", worktree, err)
+		}
+	}()
+
+	return Extract(ctx, filepath.Join(worktree, rel), opts)
+}
+
+// git runs a git command in dir and returns its trimmed standard output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,269 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// graphIndex looks up the nodes and edges of a graph by ID, label and
+// endpoint. It is read-only once built, so requests may share it.
+type graphIndex struct {
+	graph *Graph
+	nodes map[string]*GraphNode
+	out   map[string][]*GraphEdge
+	in    map[string][]*GraphEdge
+}
+
+func newGraphIndex(graph *Graph) *graphIndex {
+	index := &graphIndex{
+		graph: graph,
+		nodes: make(map[string]*GraphNode, len(graph.Elements.Nodes)),
+		out:   make(map[string][]*GraphEdge),
+		in:    make(map[string][]*GraphEdge),
+	}
+	for i := range graph.Elements.Nodes {
+		node := &graph.Elements.Nodes[i]
+		index.nodes[node.Data.ID] = node
+	}
+	for i := range graph.Elements.Edges {
+		edge := &graph.Elements.Edges[i]
+		index.out[edge.Data.Source] = append(index.out[edge.Data.Source], edge)
+		index.in[edge.Data.Target] = append(index.in[edge.Data.Target], edge)
+	}
+	return index
+}
+
+// subgraph is the response of the neighbors and paths endpoints, in the
+// Cytoscape element format of graph.json.
+type subgraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// NewServer returns an HTTP handler serving graph as a read-only JSON API:
+//
+//	GET /graph                                  the whole graph, as in graph.json
+//	GET /nodes?label=Type&limit=&offset=        nodes, optionally filtered by label
+//	GET /nodes/{id}                             one node
+//	GET /nodes/{id}/neighbors?edge=&direction=  adjacent nodes and the edges to them
+//	GET /paths?from=&to=&edge=&direction=       a shortest path between two nodes
+//
+// Node IDs contain slashes and should be path-escaped, although unescaped IDs
+// work as long as they are unambiguous. edge takes comma-separated edge
+// labels and direction is out, in or both; neighbors default to both and
+// paths to out.
+func NewServer(graph *Graph) http.Handler {
+	index := newGraphIndex(graph)
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /graph", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		WriteJSON(w, graph)
+	})
+	mux.HandleFunc("GET /nodes", index.serveNodes)
+	mux.HandleFunc("GET /nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		index.serveNode(w, r.PathValue("id"))
+	})
+	mux.HandleFunc("GET /nodes/{id}/neighbors", func(w http.ResponseWriter, r *http.Request) {
+		index.serveNeighbors(w, r, r.PathValue("id"))
+	})
+	// Unescaped IDs span several path segments
+	mux.HandleFunc("GET /nodes/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		path := r.PathValue("path")
+		if id, ok := strings.CutSuffix(path, "/neighbors"); ok && index.nodes[path] == nil {
+			index.serveNeighbors(w, r, id)
+			return
+		}
+		index.serveNode(w, path)
+	})
+	mux.HandleFunc("GET /paths", index.servePath)
+
+	return mux
+}
+
+func (index *graphIndex) serveNodes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	offset, err := intParam(query.Get("offset"), 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid offset: %v", err)
+		return
+	}
+	limit, err := intParam(query.Get("limit"), -1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit: %v", err)
+		return
+	}
+
+	label := query.Get("label")
+	nodes := []GraphNode{}
+	for _, node := range index.graph.Elements.Nodes {
+		if label != "" && !slices.Contains(node.Data.Labels, label) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if limit >= 0 && len(nodes) == limit {
+			break
+		}
+		nodes = append(nodes, node)
+	}
+	writeResponse(w, nodes)
+}
+
+func (index *graphIndex) serveNode(w http.ResponseWriter, id string) {
+	node, ok := index.nodes[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no node %q", id)
+		return
+	}
+	writeResponse(w, node)
+}
+
+func (index *graphIndex) serveNeighbors(w http.ResponseWriter, r *http.Request, id string) {
+	if _, ok := index.nodes[id]; !ok {
+		writeError(w, http.StatusNotFound, "no node %q", id)
+		return
+	}
+	follow, err := index.edgeFilter(r, "both")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	result := subgraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	seen := map[string]bool{}
+	for _, step := range follow(id) {
+		result.Edges = append(result.Edges, *step.edge)
+		if node, ok := index.nodes[step.next]; ok && !seen[step.next] {
+			seen[step.next] = true
+			result.Nodes = append(result.Nodes, *node)
+		}
+	}
+	writeResponse(w, result)
+}
+
+// servePath answers with a shortest path, found breadth-first, as the nodes
+// from "from" to "to" and the edges between them in order.
+func (index *graphIndex) servePath(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	for _, id := range []string{from, to} {
+		if _, ok := index.nodes[id]; !ok {
+			writeError(w, http.StatusNotFound, "no node %q", id)
+			return
+		}
+	}
+	follow, err := index.edgeFilter(r, "out")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	via := map[string]*GraphEdge{from: nil}
+	queue := []string{from}
+	for len(queue) > 0 && !containsKey(via, to) {
+		current := queue[0]
+		queue = queue[1:]
+		for _, step := range follow(current) {
+			if !containsKey(via, step.next) {
+				via[step.next] = step.edge
+				queue = append(queue, step.next)
+			}
+		}
+	}
+	if !containsKey(via, to) {
+		writeError(w, http.StatusNotFound, "no path from %q to %q", from, to)
+		return
+	}
+
+	result := subgraph{Nodes: []GraphNode{*index.nodes[to]}, Edges: []GraphEdge{}}
+	for current := to; current != from; {
+		edge := via[current]
+		result.Edges = append(result.Edges, *edge)
+		if current == edge.Data.Target {
+			current = edge.Data.Source
+		} else {
+			current = edge.Data.Target
+		}
+		result.Nodes = append(result.Nodes, *index.nodes[current])
+	}
+	slices.Reverse(result.Nodes)
+	slices.Reverse(result.Edges)
+	writeResponse(w, result)
+}
+
+// step is an edge followed from a node, and the node it leads to.
+type step struct {
+	edge *GraphEdge
+	next string
+}
+
+// edgeFilter returns a function listing the steps the edge and direction
+// parameters of r allow from a node.
+func (index *graphIndex) edgeFilter(r *http.Request, defaultDirection string) (func(id string) []step, error) {
+	query := r.URL.Query()
+	direction := query.Get("direction")
+	if direction == "" {
+		direction = defaultDirection
+	}
+	if direction != "out" && direction != "in" && direction != "both" {
+		return nil, fmt.Errorf("direction must be out, in or both, not %q", direction)
+	}
+	var labels []string
+	if edge := query.Get("edge"); edge != "" {
+		labels = strings.Split(edge, ",")
+	}
+
+	return func(id string) []step {
+		var steps []step
+		if direction != "in" {
+			for _, edge := range index.out[id] {
+				if labels == nil || slices.Contains(labels, edge.Data.Label) {
+					steps = append(steps, step{edge, edge.Data.Target})
+				}
+			}
+		}
+		if direction != "out" {
+			for _, edge := range index.in[id] {
+				if labels == nil || slices.Contains(labels, edge.Data.Label) {
+					steps = append(steps, step{edge, edge.Data.Source})
+				}
+			}
+		}
+		return steps
+	}, nil
+}
+
+func containsKey[V any](m map[string]V, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// intParam parses a non-negative integer query parameter, returning def when
+// it is absent.
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("%d is negative", n)
+	}
+	return n, err
+}
+
+func writeResponse(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,103 @@
+package extractor_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(extractor.NewServer(&extractor.Graph{Elements: extractor.Elements{
+		Nodes: []extractor.GraphNode{
+			testNode("example.com/m/main.go", "File", nil),
+			testNode("example.com/m.main", "Operation", nil),
+			testNode("example.com/m.run", "Operation", nil),
+			testNode("example.com/m.T", "Type", nil),
+		},
+		Edges: []extractor.GraphEdge{
+			testEdge("example.com/m/main.go", "example.com/m.main", "declares", nil),
+			testEdge("example.com/m.main", "example.com/m.run", "invokes", nil),
+			testEdge("example.com/m.run", "example.com/m.T", "typed", nil),
+		},
+	}}))
+	defer server.Close()
+
+	// get decodes the JSON response to path into v and returns the status
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s returned invalid JSON: %v", path, err)
+		}
+		return resp.StatusCode
+	}
+	ids := func(nodes []extractor.GraphNode) []string {
+		var ids []string
+		for _, node := range nodes {
+			ids = append(ids, node.Data.ID)
+		}
+		return ids
+	}
+
+	var nodes []extractor.GraphNode
+	if get("/nodes?label=Operation", &nodes); len(nodes) != 2 {
+		t.Errorf("/nodes?label=Operation = %v", ids(nodes))
+	}
+	if get("/nodes?limit=1&offset=1", &nodes); len(nodes) != 1 || nodes[0].Data.ID != "example.com/m.main" {
+		t.Errorf("/nodes?limit=1&offset=1 = %v", ids(nodes))
+	}
+
+	var node extractor.GraphNode
+	for _, path := range []string{"/nodes/" + url.PathEscape("example.com/m/main.go"), "/nodes/example.com/m/main.go"} {
+		if status := get(path, &node); status != http.StatusOK || node.Data.ID != "example.com/m/main.go" {
+			t.Errorf("%s = %d %v", path, status, node.Data.ID)
+		}
+	}
+	var failure map[string]string
+	if status := get("/nodes/missing", &failure); status != http.StatusNotFound || failure["error"] == "" {
+		t.Errorf("/nodes/missing = %d %v", status, failure)
+	}
+
+	var neighbors struct {
+		Nodes []extractor.GraphNode `json:"nodes"`
+		Edges []extractor.GraphEdge `json:"edges"`
+	}
+	get("/nodes/example.com%2Fm.run/neighbors?edge=invokes&direction=in", &neighbors)
+	if got := ids(neighbors.Nodes); len(got) != 1 || got[0] != "example.com/m.main" || len(neighbors.Edges) != 1 {
+		t.Errorf("neighbors of run = %v", got)
+	}
+	if get("/nodes/example.com/m.run/neighbors", &neighbors); len(neighbors.Nodes) != 2 {
+		t.Errorf("neighbors of run in both directions = %v", ids(neighbors.Nodes))
+	}
+	if status := get("/nodes/example.com%2Fm.run/neighbors?direction=up", &failure); status != http.StatusBadRequest {
+		t.Errorf("invalid direction answered %d", status)
+	}
+
+	var path struct {
+		Nodes []extractor.GraphNode `json:"nodes"`
+		Edges []extractor.GraphEdge `json:"edges"`
+	}
+	get("/paths?from=example.com/m/main.go&to=example.com/m.T", &path)
+	if got := ids(path.Nodes); len(got) != 4 || got[0] != "example.com/m/main.go" || got[3] != "example.com/m.T" || len(path.Edges) != 3 {
+		t.Errorf("path = %v", got)
+	}
+	if status := get("/paths?from=example.com/m.T&to=example.com/m.main", &failure); status != http.StatusNotFound {
+		t.Errorf("path against the edges answered %d", status)
+	}
+	if get("/paths?from=example.com/m.T&to=example.com/m.main&direction=both", &path); len(path.Edges) != 2 {
+		t.Errorf("undirected path = %v", ids(path.Nodes))
+	}
+
+	var graph extractor.Graph
+	if get("/graph", &graph); len(graph.Elements.Nodes) != 4 || len(graph.Elements.Edges) != 3 {
+		t.Errorf("/graph returned %d nodes and %d edges", len(graph.Elements.Nodes), len(graph.Elements.Edges))
+	}
+}
@@ -2,15 +2,148 @@ package extractor
 
 import (
 	"fmt"
+	"go/token"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
+// toNodeID derives an ID from a file path or position key. It is only used for
+// symbols collected without package information; every other node is
+// identified by its import path based qualified name.
 func toNodeID(path string) string {
 	clean := strings.TrimSuffix(strings.ReplaceAll(path, "\\", "."), ".go")
 	return strings.TrimLeft(clean, ".")
 }
 
+// qualify joins owner and the non-empty names with dots. The owner is a
+// package path or an already qualified name; without it no portable name can
+// be formed and qualify returns "".
+func qualify(owner string, names ...string) string {
+	if owner == "" {
+		return ""
+	}
+	qualified := owner
+	for _, name := range names {
+		if name != "" {
+			qualified += "." + name
+		}
+	}
+	return qualified
+}
+
+// baseTypeName strips pointers and type arguments from a receiver type,
+// e.g. "*List[T]" becomes "List".
+func baseTypeName(typ string) string {
+	typ = strings.TrimLeft(typ, "*")
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		typ = typ[:i]
+	}
+	if i := strings.LastIndexByte(typ, '.'); i >= 0 {
+		typ = typ[i+1:]
+	}
+	return typ
+}
+
+// symbolID returns the node ID of the definition stored under key.
+func symbolID(symbols map[string]*ModifiedDefinitionInfo, key string) (string, bool) {
+	def, ok := symbols[key]
+	if !ok {
+		return "", false
+	}
+	return definitionID(key, def), true
+}
+
+// definitionID prefers the portable qualified name of def and only falls back
+// to its position for symbols collected without package information.
+func definitionID(key string, def *ModifiedDefinitionInfo) string {
+	if def.QualifiedName != "" {
+		return def.QualifiedName
+	}
+	return toNodeID(key)
+}
+
+// scopeNodeID identifies the Scope node of a package.
+func scopeNodeID(packagePath string) string {
+	return packagePath + ".package"
+}
+
+// fileNodeID identifies the File node of the Go file at uri, which belongs to
+// the package with the given import path.
+func fileNodeID(packagePath, uri string) string {
+	if packagePath == "" {
+		return toNodeID(strings.TrimPrefix(uri, "file://")) + ".go"
+	}
+	if strings.HasSuffix(uri, "_test.go") {
+		// External test packages live in the directory of the package they test
+		packagePath = strings.TrimSuffix(packagePath, "_test")
+	}
+	return packagePath + "/" + path.Base(uri)
+}
+
+// importPathOf returns the import path dir has within its enclosing module,
+// which is also the ID of its Folder node. Outside of a module the
+// slash-separated absolute path is used instead.
+func importPathOf(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+
+	for d := absDir; ; d = filepath.Dir(d) {
+		if data, err := os.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			if modPath := modfile.ModulePath(data); modPath != "" {
+				rel, err := filepath.Rel(d, absDir)
+				if err != nil {
+					break
+				}
+				return path.Join(modPath, filepath.ToSlash(rel))
+			}
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	return filepath.ToSlash(absDir)
+}
+
+// relativeNodeID identifies the Folder or File node at p, given the import
+// path of the project root.
+func relativeNodeID(sourceRoot, rootImportPath, p string) string {
+	rel, err := filepath.Rel(sourceRoot, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return path.Join(rootImportPath, filepath.ToSlash(rel))
+}
+
+// qualifiedTypeName names a type definition by the import path of its package,
+// falling back to the package name for symbols loaded without one.
+func qualifiedTypeName(def *ModifiedDefinitionInfo) string {
+	switch {
+	case def.PackagePath != "":
+		return def.PackagePath + "." + def.Name
+	case def.PackageName != "":
+		return def.PackageName + "." + def.Name
+	}
+	return def.Name
+}
+
+// positionKey formats pos the way simplified AST positions and symbol table
+// keys are formatted, so type-checker objects can be looked up by position.
+func positionKey(fset *token.FileSet, pos token.Pos) string {
+	position := fset.Position(pos)
+	absPath, err := filepath.Abs(position.Filename)
+	if err != nil {
+		absPath = position.Filename
+	}
+	return fmt.Sprintf("file://%s:%d:%d", filepath.ToSlash(absPath), position.Line-1, position.Column-1)
+}
+
 func isPrimitiveType(name string) bool {
 	switch name {
 	case "int", "int8", "int16", "int32", "int64",
@@ -0,0 +1,357 @@
+// Offline viewer for a gophers knowledge graph embedded in the page as
+// Cytoscape.js JSON, which is handed to the vendored Cytoscape.js as it is.
+// Folder, File and Scope nodes can be collapsed into a single node standing
+// in for everything they contain; the edges of the nodes they hide are then
+// drawn between the collapsed nodes.
+(function () {
+  "use strict";
+
+  const data = JSON.parse(document.getElementById("graph-data").textContent);
+  const elements = data.elements || {};
+  const nodeCount = (elements.nodes || []).length;
+  const edgeCount = (elements.edges || []).length;
+
+  // Nodes of these labels collapse along these edges
+  const hierarchyLabels = new Set(["Folder", "File", "Scope"]);
+  const hierarchyEdges = new Set(["contains", "declares", "encloses"]);
+
+  const palette = [
+    "#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948",
+    "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac", "#86bcb6", "#d37295",
+  ];
+  const colors = new Map();
+
+  function primaryLabel(node) {
+    const labels = node.data("labels");
+    return labels && labels.length ? labels[0] : "";
+  }
+
+  function colorOf(node) {
+    const label = primaryLabel(node);
+    if (!colors.has(label)) {
+      colors.set(label, palette[colors.size % palette.length]);
+    }
+    return colors.get(label);
+  }
+
+  function nameOf(node) {
+    const properties = node.data("properties");
+    return (properties && properties.simpleName) || node.id();
+  }
+
+  // Number of nodes hidden by each collapsed node that is shown
+  let hiddenCounts = new Map();
+
+  function size(node) {
+    return hiddenCounts.has(node.id()) ? 20 + 2 * Math.min(Math.sqrt(hiddenCounts.get(node.id())), 12) : 14;
+  }
+
+  const cy = cytoscape({
+    container: document.getElementById("graph"),
+    elements: elements,
+    layout: { name: "preset" },
+    style: [
+      {
+        selector: "node",
+        style: {
+          "background-color": colorOf,
+          "label": (node) => {
+            const hidden = hiddenCounts.get(node.id());
+            return hidden ? `${nameOf(node)} (+${hidden})` : nameOf(node);
+          },
+          "width": size,
+          "height": size,
+          "font-size": 11,
+          "color": "#222",
+          "text-valign": "center",
+          "text-halign": "right",
+          "text-margin-x": 3,
+          "min-zoomed-font-size": 7,
+        },
+      },
+      {
+        selector: "edge",
+        style: {
+          "width": 1,
+          "line-color": "#c8c8c8",
+          "target-arrow-color": "#c8c8c8",
+          "target-arrow-shape": "triangle",
+          "arrow-scale": 0.7,
+          "curve-style": "bezier",
+          "label": (edge) => (edge.data("count") > 1 ? `${edge.data("label")} ×${edge.data("count")}` : edge.data("label")),
+          "font-size": 9,
+          "text-rotation": "autorotate",
+          "min-zoomed-font-size": 14,
+        },
+      },
+      { selector: "edge.merged", style: { "line-style": "dashed" } },
+      { selector: ".hidden", style: { "display": "none" } },
+      { selector: "node.match", style: { "border-width": 3, "border-color": "#e6b800" } },
+      { selector: "node:selected", style: { "border-width": 3, "border-color": "#000" } },
+      {
+        selector: "edge.highlighted",
+        style: { "line-color": "#333", "target-arrow-color": "#333", "min-zoomed-font-size": 0 },
+      },
+    ],
+  });
+
+  // Every node has at most one parent in the hierarchy
+  const parent = new Map();
+  const children = new Map();
+  cy.edges().forEach((edge) => {
+    const source = edge.data("source");
+    const target = edge.data("target");
+    if (source === target || !hierarchyEdges.has(edge.data("label"))) return;
+    if (!hierarchyLabels.has(primaryLabel(edge.source())) || parent.has(target)) return;
+    parent.set(target, source);
+    if (!children.has(source)) children.set(source, []);
+    children.get(source).push(target);
+  });
+
+  function ancestors(id) {
+    const result = [];
+    const seen = new Set([id]);
+    for (let p = parent.get(id); p !== undefined && !seen.has(p); p = parent.get(p)) {
+      seen.add(p);
+      result.push(p);
+    }
+    return result;
+  }
+
+  function descendantCount(id) {
+    let count = 0;
+    const seen = new Set([id]);
+    const stack = [id];
+    while (stack.length) {
+      for (const child of children.get(stack.pop()) || []) {
+        if (seen.has(child)) continue;
+        seen.add(child);
+        stack.push(child);
+        count++;
+      }
+    }
+    return count;
+  }
+
+  const collapsible = (id) => children.has(id);
+  const collapsed = new Set();
+
+  // A hidden node is drawn as its outermost collapsed ancestor
+  function representative(id) {
+    let rep = id;
+    for (const ancestor of ancestors(id)) {
+      if (collapsed.has(ancestor)) rep = ancestor;
+    }
+    return rep;
+  }
+
+  // Large graphs start with their files and scopes collapsed
+  if (nodeCount > 300) {
+    cy.nodes().forEach((node) => {
+      const label = primaryLabel(node);
+      if ((label === "File" || label === "Scope") && collapsible(node.id())) collapsed.add(node.id());
+    });
+  }
+
+  // refresh hides the nodes inside collapsed ones and stands in for their
+  // edges with merged edges between the nodes that are shown
+  function refresh() {
+    cy.batch(() => {
+      cy.edges(".merged").remove();
+      hiddenCounts = new Map();
+      for (const id of collapsed) {
+        if (representative(id) === id) hiddenCounts.set(id, descendantCount(id));
+      }
+
+      cy.nodes().forEach((node) => {
+        node.toggleClass("hidden", representative(node.id()) !== node.id());
+      });
+
+      const merged = new Map();
+      cy.edges().forEach((edge) => {
+        const source = representative(edge.data("source"));
+        const target = representative(edge.data("target"));
+        const moved = source !== edge.data("source") || target !== edge.data("target");
+        edge.toggleClass("hidden", moved);
+        if (!moved || source === target) return;
+        const key = source + "\u0000" + target + "\u0000" + edge.data("label");
+        if (!merged.has(key)) merged.set(key, { source, target, label: edge.data("label"), count: 0 });
+        merged.get(key).count++;
+      });
+      let i = 0;
+      for (const edge of merged.values()) {
+        cy.add({ group: "edges", classes: "merged", data: { id: `merged:${i++}`, ...edge } });
+      }
+      cy.nodes().updateStyle();
+    });
+
+    const shown = cy.nodes().not(".hidden").length;
+    document.getElementById("summary").textContent = `${nodeCount} nodes, ${edgeCount} edges (${shown} nodes shown)`;
+    if (cy.nodes(":selected.hidden").nonempty()) select(null);
+  }
+
+  function shown() {
+    return cy.elements().not(".hidden");
+  }
+
+  function layout() {
+    shown().layout({
+      name: "cose",
+      animate: false,
+      randomize: false,
+      fit: false,
+      idealEdgeLength: () => 60,
+      numIter: nodeCount > 2000 ? 300 : 1000,
+    }).run();
+  }
+
+  function fit() {
+    cy.fit(shown(), 30);
+  }
+
+  function toggle(id) {
+    if (collapsed.has(id)) {
+      collapsed.delete(id);
+    } else {
+      collapsed.add(id);
+    }
+    refresh();
+    layout();
+    if (cy.getElementById(id).selected()) select(id);
+  }
+
+  function reveal(id) {
+    let changed = false;
+    for (const ancestor of ancestors(id)) changed = collapsed.delete(ancestor) || changed;
+    if (changed) {
+      refresh();
+      layout();
+    }
+  }
+
+  function focus(id) {
+    reveal(id);
+    select(id);
+    cy.animate({ center: { eles: cy.getElementById(id) }, duration: 200 });
+  }
+
+  // Sidebar
+  function row(table, key, value) {
+    const tr = table.insertRow();
+    const th = document.createElement("th");
+    th.textContent = key;
+    tr.appendChild(th);
+    tr.insertCell().textContent = value;
+  }
+
+  function select(id) {
+    cy.elements().unselect();
+    cy.edges(".highlighted").removeClass("highlighted");
+    const details = document.getElementById("details");
+    details.hidden = id === null;
+    if (id === null) return;
+
+    const node = cy.getElementById(id);
+    node.select();
+    node.connectedEdges().addClass("highlighted");
+    document.getElementById("details-name").textContent = nameOf(node);
+    const table = document.getElementById("details-table");
+    table.replaceChildren();
+    row(table, "id", id);
+    row(table, "labels", (node.data("labels") || []).join(", "));
+    const properties = node.data("properties") || {};
+    for (const key of Object.keys(properties).sort()) row(table, key, properties[key]);
+    row(table, "edges out", node.outgoers("edge").not(".merged").length);
+    row(table, "edges in", node.incomers("edge").not(".merged").length);
+
+    const button = document.getElementById("toggle");
+    button.hidden = !collapsible(id);
+    button.textContent = collapsed.has(id) ? "Expand" : "Collapse";
+  }
+
+  cy.on("tap", (event) => {
+    if (event.target === cy) select(null);
+  });
+  cy.on("tap", "node", (event) => select(event.target.id()));
+  cy.on("dbltap", "node", (event) => {
+    if (collapsible(event.target.id())) toggle(event.target.id());
+  });
+
+  document.getElementById("toggle").addEventListener("click", () => {
+    const selected = cy.nodes(":selected");
+    if (selected.nonempty()) toggle(selected.id());
+  });
+
+  document.getElementById("search").addEventListener("input", (event) => {
+    const query = event.target.value.trim().toLowerCase();
+    const list = document.getElementById("matches");
+    list.replaceChildren();
+    cy.nodes(".match").removeClass("match");
+    if (!query) return;
+
+    const matches = cy.nodes().filter((node) => nameOf(node).toLowerCase().includes(query));
+    matches.addClass("match");
+    matches.slice(0, 50).forEach((node) => {
+      const item = document.createElement("li");
+      const swatch = document.createElement("span");
+      swatch.className = "swatch";
+      swatch.style.background = colorOf(node);
+      item.append(swatch, nameOf(node));
+      item.title = node.id();
+      item.addEventListener("click", () => focus(node.id()));
+      list.appendChild(item);
+    });
+    if (matches.length > 50) {
+      const more = document.createElement("li");
+      more.textContent = `${matches.length - 50} more`;
+      more.className = "count";
+      list.appendChild(more);
+    }
+  });
+
+  document.getElementById("search").addEventListener("keydown", (event) => {
+    const first = cy.nodes(".match").first();
+    if (event.key === "Enter" && first.nonempty()) focus(first.id());
+  });
+
+  document.getElementById("collapse-all").addEventListener("click", () => {
+    for (const id of children.keys()) collapsed.add(id);
+    refresh();
+    layout();
+    fit();
+  });
+
+  document.getElementById("expand-all").addEventListener("click", () => {
+    collapsed.clear();
+    refresh();
+    layout();
+  });
+
+  document.getElementById("fit").addEventListener("click", fit);
+
+  function legend() {
+    const counts = new Map();
+    cy.nodes().forEach((node) => {
+      colorOf(node);
+      const label = primaryLabel(node);
+      counts.set(label, (counts.get(label) || 0) + 1);
+    });
+    const list = document.getElementById("legend");
+    for (const [label, color] of colors) {
+      const item = document.createElement("li");
+      const swatch = document.createElement("span");
+      swatch.className = "swatch";
+      swatch.style.background = color;
+      const count = document.createElement("span");
+      count.className = "count";
+      count.textContent = counts.get(label);
+      item.append(swatch, label || "(none)", count);
+      list.appendChild(item);
+    }
+  }
+
+  legend();
+  refresh();
+  layout();
+  fit();
+})();
@@ -0,0 +1,83 @@
+package extractor
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// parallel calls fn with every index below n on up to workers goroutines. It
+// returns the first error fn returns, or the context's error once ctx is
+// done; indices not started by then are skipped.
+func parallel(ctx context.Context, workers, n int, fn func(i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	jobs := make(chan int)
+
+	for range max(min(workers, n), 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range n {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// workers returns how many goroutines the pipeline stages may use.
+func (opts Options) workers() int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// edgeGenerator produces one group of edges. Generators only read the
+// simplified ASTs, symbol table and packages they close over, so any number
+// of them can run at once.
+type edgeGenerator func() []GraphEdge
+
+// runEdgeGenerators runs generators on up to workers goroutines and
+// concatenates their edges in the order of generators, so the result does
+// not depend on scheduling.
+func runEdgeGenerators(ctx context.Context, workers int, generators []edgeGenerator) ([]GraphEdge, error) {
+	results := make([][]GraphEdge, len(generators))
+	err := parallel(ctx, workers, len(generators), func(i int) error {
+		results[i] = generators[i]()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Concat(results...), nil
+}
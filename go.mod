@@ -3,11 +3,13 @@ module github.com/rayhanp1402/gophers
 go 1.23.2
 
 require (
+	github.com/fsnotify/fsnotify v1.10.1
+	golang.org/x/mod v0.26.0
 	golang.org/x/text v0.27.0
 	golang.org/x/tools v0.35.0
 )
 
 require (
-	golang.org/x/mod v0.26.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
 )
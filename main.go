@@ -1,12 +1,15 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/rayhanp1402/gophers/extractor"
@@ -15,17 +18,40 @@ import (
 const (
 	IntermediateDir = "./intermediate_representation"
 	OutputDir       = "./knowledge_graph"
-	OutputFileName  = "graph.json"
+	OutputFileName  = "graph"
 	SymbolTableFile = "symbol_table.txt"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "cache":
+			runCache(os.Args[2:])
+			return
+		case "watch":
+			runWatch(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		}
+	}
+
 	start := time.Now()
 
 	// Parse command-line arguments
-	debug := flag.Bool("debug", false, "Keep intermediate files and symbol table for debugging")
+	flags := addExtractFlags(flag.CommandLine)
+	outputs := addOutputFlags(flag.CommandLine)
+	timeout := flag.Duration("timeout", 0, "Abort the extraction after this long, e.g. 5m (default no limit)")
 	flag.Usage = func() {
-		fmt.Println("Usage: go run main.go [flags] <directory>")
+		fmt.Println("Usage: go run . [flags] <directory>")
+		fmt.Println("       go run . diff [flags] <old> <new>")
+		fmt.Println("       go run . watch [flags] <directory>")
+		fmt.Println("       go run . serve [flags] <directory>")
+		fmt.Println("       go run . cache prune [flags]")
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -37,106 +63,222 @@ func main() {
 
 	inputDir := flag.Arg(0)
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(inputDir)
+	outputFormat, err := outputs.outputFormat()
 	if err != nil {
-		log.Fatalf("Failed to resolve absolute path: %v", err)
+		log.Fatal(err)
 	}
+	output := *outputs.output
 
-	// Parse Go source files
-	fset, parsedFiles, err := extractor.ParsePackage(inputDir)
+	// Status messages must not end up in the graph when it goes to stdout
+	var status io.Writer = os.Stdout
+	if output == "-" {
+		status = os.Stderr
+	}
+
+	opts, err := flags.options()
 	if err != nil {
-		log.Fatalf("Failed to parse package: %v", err)
+		log.Fatal(err)
 	}
-	fmt.Println("Processing files...")
 
-	// Load type information
-	typesInfo, typesPkg, err := extractor.LoadTypesInfo(fset, parsedFiles, absPath)
+	// Interrupting or running out of time cancels the extraction
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	fmt.Fprintln(status, "Processing files...")
+	graph, err := extractor.Extract(ctx, inputDir, opts)
 	if err != nil {
-		log.Fatalf("Failed to load types info: %v", err)
+		log.Fatalf("Extraction failed: %v", err)
+	}
+	if opts.IntermediateDir != "" {
+		fmt.Fprintln(status, "Simplified ASTs written to:", opts.IntermediateDir)
+		fmt.Fprintln(status, "Symbol table written to:", opts.SymbolTableFile)
 	}
-	fmt.Println("Loaded types for package:", typesPkg.Name())
 
-	// Output simplified ASTs
-	err = extractor.OutputSimplifiedASTs(fset, parsedFiles, absPath, IntermediateDir, typesInfo)
+	written, err := writeGraph(graph, outputFormat, output)
 	if err != nil {
-		log.Fatalf("Failed to write simplified ASTs: %v", err)
+		log.Fatalf("Failed to write graph: %v", err)
+	}
+	if written != "-" {
+		fmt.Fprintln(status, "Graph written to:", written)
+	}
+
+	elapsed := time.Since(start)
+	fmt.Fprintf(status, "Extraction completed in %s\n", elapsed)
+}
+
+// extractFlags are the flags selecting what to extract, shared by every
+// command that extracts a project.
+type extractFlags struct {
+	debug           *bool
+	callGraph       *string
+	inherits        *bool
+	tests           *bool
+	testDepth       *int
+	tags            *string
+	goos            *string
+	goarch          *string
+	external        *bool
+	vendor          *bool
+	buildContexts   *string
+	workers         *int
+	noCache         *bool
+	cacheDir        *string
+	intermediateDir *string
+}
+
+// addExtractFlags defines the extraction flags on fs.
+func addExtractFlags(fs *flag.FlagSet) *extractFlags {
+	return &extractFlags{
+		debug:           fs.Bool("debug", false, "Keep intermediate files and symbol table for debugging"),
+		callGraph:       fs.String("callgraph", "", "Resolve interface and function-value calls with a call graph algorithm: cha, rta or vta"),
+		inherits:        fs.Bool("inherits", false, "Add inherits edges for methods and fields promoted through embedding"),
+		tests:           fs.Bool("tests", false, "Include _test.go files and add tests edges from test functions to the operations they exercise"),
+		testDepth:       fs.Int("test-depth", 1, "Number of invokes edges a tests edge may span (with -tests)"),
+		tags:            fs.String("tags", "", "Comma-separated build tags to satisfy"),
+		goos:            fs.String("goos", "", "Target operating system (default $GOOS or the host's)"),
+		goarch:          fs.String("goarch", "", "Target architecture (default $GOARCH or the host's)"),
+		external:        fs.Bool("external", false, "Add nodes for imported standard library and third-party packages and the symbols the project uses from them"),
+		vendor:          fs.Bool("vendor", false, "Also extract the packages vendored under vendor/ directories"),
+		buildContexts:   fs.String("build-contexts", "", "Comma-separated GOOS/GOARCH pairs to extract and merge, e.g. linux/amd64,windows/amd64"),
+		workers:         fs.Int("workers", 0, "Number of files and edge generators processed concurrently (default GOMAXPROCS)"),
+		noCache:         fs.Bool("no-cache", false, "Extract from scratch, neither reading nor writing the cache"),
+		cacheDir:        fs.String("cache-dir", "", "Cache directory (default the user cache directory's gophers folder)"),
+		intermediateDir: fs.String("intermediate-dir", "", "Write the simplified ASTs and symbol table into this directory"),
+	}
+}
+
+// outputFlags are the flags selecting how and where to write the graph.
+type outputFlags struct {
+	format *string
+	output *string
+}
+
+// addOutputFlags defines the output flags on fs.
+func addOutputFlags(fs *flag.FlagSet) *outputFlags {
+	return &outputFlags{
+		format: fs.String("format", "json", "Output format: "+strings.Join(extractor.FormatNames(), ", ")),
+		output: fs.String("o", "", "Output path, or - for stdout (default "+OutputDir+"/"+OutputFileName+".<format>)"),
+	}
+}
+
+// outputFormat returns the format selected by -format.
+func (f *outputFlags) outputFormat() (extractor.Format, error) {
+	format, ok := extractor.LookupFormat(*f.format)
+	if !ok {
+		return extractor.Format{}, fmt.Errorf("unknown output format %q (available: %s)", *f.format, strings.Join(extractor.FormatNames(), ", "))
+	}
+	if _, ok := format.Exporter.(extractor.DirExporter); ok && *f.output == "-" {
+		return extractor.Format{}, fmt.Errorf("the %s format writes a directory and cannot be written to stdout", *f.format)
 	}
-	fmt.Println("Simplified ASTs written to:", IntermediateDir)
+	return format, nil
+}
 
-	// Load simplified ASTs
-	simplifiedASTs, err := extractor.LoadSimplifiedASTs(IntermediateDir)
+// options returns the extraction options selected by the flags.
+func (f *extractFlags) options() (extractor.Options, error) {
+	contexts, err := parseBuildContexts(*f.buildContexts, *f.goos, *f.goarch, *f.tags)
 	if err != nil {
-		log.Fatalf("Failed to load simplified ASTs: %v", err)
+		return extractor.Options{}, err
 	}
 
-	// Build symbol table
-	symbolTable := make(map[string]*extractor.ModifiedDefinitionInfo)
-	for _, root := range simplifiedASTs {
-		for name, def := range extractor.CollectSymbolTable(root) {
-			symbolTable[name] = def
+	opts := extractor.Options{
+		CallGraph:     *f.callGraph,
+		Inherits:      *f.inherits,
+		Tests:         *f.tests,
+		TestDepth:     *f.testDepth,
+		BuildContexts: contexts,
+		Vendor:        *f.vendor,
+		External:      *f.external,
+		Workers:       *f.workers,
+	}
+	if !*f.noCache {
+		// Without a usable cache directory extraction still works, only slower
+		if opts.CacheDir, err = resolveCacheDir(*f.cacheDir); err != nil {
+			log.Print(err)
 		}
 	}
+	switch {
+	case *f.intermediateDir != "":
+		opts.IntermediateDir = *f.intermediateDir
+		opts.SymbolTableFile = filepath.Join(*f.intermediateDir, SymbolTableFile)
+	case *f.debug:
+		opts.IntermediateDir = IntermediateDir
+		opts.SymbolTableFile = SymbolTableFile
+	}
+	return opts, nil
+}
 
-	// Optionally write symbol table
-	if *debug {
-		if err := extractor.WriteSymbolTableToFile(symbolTable, SymbolTableFile); err != nil {
-			log.Fatalf("Failed to write symbol table: %v", err)
-		}
-		fmt.Println("Symbol table written to:", SymbolTableFile)
+// parseBuildContexts turns the build flags into the build contexts to extract:
+// one per pair of list, or the single context of goos and goarch. Every
+// context gets the comma-separated tags.
+func parseBuildContexts(list, goos, goarch, tags string) ([]extractor.BuildContext, error) {
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
 	}
 
-	// Save updated ASTs with declaration info
-	for _, root := range simplifiedASTs {
-		if err := extractor.SaveSimplifiedAST(root, absPath, IntermediateDir); err != nil {
-			log.Printf("Warning: failed to save updated AST: %v", err)
+	if list == "" {
+		if goos == "" && goarch == "" && tagList == nil {
+			return nil, nil
 		}
+		return []extractor.BuildContext{{GOOS: goos, GOARCH: goarch, Tags: tagList}}, nil
+	}
+	if goos != "" || goarch != "" {
+		return nil, fmt.Errorf("-build-contexts cannot be combined with -goos or -goarch")
 	}
 
-	// Generate graph data
-	nodes, err := extractor.GenerateGraphNodes(absPath, parsedFiles, symbolTable, simplifiedASTs)
-	if err != nil {
-		log.Fatalf("Failed to generate graph nodes: %v", err)
+	var contexts []extractor.BuildContext
+	for _, pair := range strings.Split(list, ",") {
+		buildContext, err := extractor.ParseBuildContext(strings.TrimSpace(pair), tagList)
+		if err != nil {
+			return nil, err
+		}
+		contexts = append(contexts, buildContext)
 	}
-	edges := extractor.GenerateAllEdges(simplifiedASTs, symbolTable, absPath)
+	return contexts, nil
+}
 
-	graph := extractor.Graph{
-		Elements: extractor.Elements{
-			Nodes: nodes,
-			Edges: edges,
-		},
+// writeGraph exports graph in format to path and returns where it was written.
+// An empty path selects the default location under OutputDir and "-" selects
+// stdout. Files are replaced atomically, so readers never see a partial graph.
+func writeGraph(graph *extractor.Graph, format extractor.Format, path string) (string, error) {
+	if dirExporter, ok := format.Exporter.(extractor.DirExporter); ok {
+		if path == "" {
+			path = OutputDir
+		}
+		return path, dirExporter.ExportDir(path, graph)
 	}
 
-	// Write graph JSON output
-	if err := os.MkdirAll(OutputDir, os.ModePerm); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+	if path == "-" {
+		return path, format.Exporter.Export(os.Stdout, graph)
+	}
+	if path == "" {
+		path = filepath.Join(OutputDir, OutputFileName+format.Extension)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
-	outputFile := filepath.Join(OutputDir, OutputFileName)
 
-	f, err := os.Create(outputFile)
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
 	if err != nil {
-		log.Fatalf("Failed to create output file: %v", err)
+		return "", fmt.Errorf("failed to create output file: %w", err)
 	}
+	defer os.Remove(f.Name())
 	defer f.Close()
 
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(graph); err != nil {
-		log.Fatalf("Failed to encode graph to JSON: %v", err)
+	if err := format.Exporter.Export(f, graph); err != nil {
+		return "", err
 	}
-
-	fmt.Println("Graph written to:", outputFile)
-
-	// Cleanup if not in debug mode
-	if !*debug {
-		if err := os.RemoveAll(IntermediateDir); err != nil {
-			log.Printf("Warning: failed to remove intermediate directory: %v", err)
-		}
-		if err := os.Remove(SymbolTableFile); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: failed to remove symbol table file: %v", err)
-		}
+	if err := f.Close(); err != nil {
+		return "", err
 	}
-
-	elapsed := time.Since(start)
-	fmt.Printf("Extraction completed in %s\n", elapsed)
+	// CreateTemp makes the file private; outputs get the usual permissions
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return "", err
+	}
+	return path, os.Rename(f.Name(), path)
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+// runServe implements `gophers serve`, which extracts a project once and
+// serves its graph over HTTP, so that dashboards can query it instead of
+// loading the whole graph.json.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags := addExtractFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	allowOrigin := fs.String("allow-origin", "", "Origin allowed to query the API from a browser, or * for any (default same origin only)")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . serve [flags] <directory>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	opts, err := flags.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	graph, err := extractor.Extract(ctx, fs.Arg(0), opts)
+	if err != nil {
+		log.Fatalf("Extraction failed: %v", err)
+	}
+
+	handler := extractor.NewServer(graph)
+	if *allowOrigin != "" {
+		api := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", *allowOrigin)
+			api.ServeHTTP(w, r)
+		})
+	}
+	server := &http.Server{Addr: *addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Extracted %d nodes and %d edges in %s; serving on http://%s\n",
+		len(graph.Elements.Nodes), len(graph.Elements.Edges), time.Since(start).Round(time.Millisecond), *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rayhanp1402/gophers/extractor"
+)
+
+// settleDelay is how long the sources must stay quiet after a notification
+// before the project is extracted again, so that a save or checkout touching
+// several files leads to a single extraction.
+const settleDelay = 100 * time.Millisecond
+
+// fileState is what polling compares to tell whether a file changed.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// runWatch implements `gophers watch`, which keeps the graph of a project up
+// to date: it watches the project's Go sources and module files through file
+// system notifications, or by polling where those are unavailable,
+// re-extracts the project when they change and rewrites the output. The
+// extraction cache limits the work to the packages affected by the change.
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	flags := addExtractFlags(fs)
+	outputs := addOutputFlags(fs)
+	interval := fs.Duration("interval", time.Second, "How often to poll the project for changes when polling")
+	poll := fs.Bool("poll", false, "Poll instead of using file system notifications, e.g. on network file systems")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . watch [flags] <directory>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	inputDir := fs.Arg(0)
+
+	outputFormat, err := outputs.outputFormat()
+	if err != nil {
+		log.Fatal(err)
+	}
+	output := *outputs.output
+	if output == "-" {
+		log.Fatal("watch mode rewrites its output and cannot write to stdout")
+	}
+	opts, err := flags.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sources, err := snapshotSources(inputDir, opts.Vendor)
+	if err != nil {
+		log.Fatal(err)
+	}
+	start := time.Now()
+	graph, err := extractor.Extract(ctx, inputDir, opts)
+	if err != nil {
+		log.Fatalf("Extraction failed: %v", err)
+	}
+	written, err := writeGraph(graph, outputFormat, output)
+	if err != nil {
+		log.Fatalf("Failed to write graph: %v", err)
+	}
+	fmt.Printf("%s graph written to %s (%d nodes, %d edges) in %s; watching for changes\n",
+		time.Now().Format(time.TimeOnly), written, len(graph.Elements.Nodes), len(graph.Elements.Edges), time.Since(start).Round(time.Millisecond))
+
+	var wake <-chan struct{}
+	if !*poll {
+		if wake, err = notifySources(ctx, inputDir, opts.Vendor); err != nil {
+			log.Printf("File system notifications are unavailable, polling every %s instead: %v", *interval, err)
+		}
+	}
+	if wake == nil {
+		wake = pollSources(ctx, *interval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+			settle(ctx, wake, settleDelay)
+		}
+
+		current, err := snapshotSources(inputDir, opts.Vendor)
+		if err != nil {
+			log.Printf("Failed to poll %s: %v", inputDir, err)
+			continue
+		}
+		changed := changedSources(sources, current)
+		if len(changed) == 0 {
+			continue
+		}
+		sources = current
+
+		start := time.Now()
+		updated, err := extractor.Extract(ctx, inputDir, opts)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Keep the last good graph; a later save may fix the error
+			log.Printf("Extraction failed: %v", err)
+			continue
+		}
+		if _, err := writeGraph(updated, outputFormat, output); err != nil {
+			log.Printf("Failed to write graph: %v", err)
+			continue
+		}
+
+		diff := extractor.DiffGraphs(graph, updated, extractor.DiffOptions{IgnoreProperties: extractor.PositionProperties})
+		graph = updated
+		fmt.Printf("%s %s changed: %s (%d nodes, %d edges) in %s\n",
+			time.Now().Format(time.TimeOnly), describeChanges(changed), diff.Summary(),
+			len(graph.Elements.Nodes), len(graph.Elements.Edges), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// notifySources returns a channel receiving a value whenever file system
+// notifications report a change below dir that may concern a source file.
+// Directories created later are watched as they appear. Notifications stop
+// when ctx is done.
+func notifySources(ctx context.Context, dir string, vendor bool) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watchDirs(watcher, dir, vendor); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if skipSourceDir(filepath.Base(event.Name), vendor) {
+							continue
+						}
+						// Files created along with the directory are found
+						// by the snapshot that follows
+						if err := watchDirs(watcher, event.Name, vendor); err != nil {
+							log.Printf("Failed to watch %s: %v", event.Name, err)
+						}
+					}
+				}
+				// A removed or renamed directory takes its sources with it
+				if isSource(filepath.Base(event.Name)) || event.Has(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) {
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("File system notifications failed: %v", err)
+			}
+		}
+	}()
+	return wake, nil
+}
+
+// watchDirs adds dir and the directories below it that may hold sources to
+// watcher.
+func watchDirs(watcher *fsnotify.Watcher, dir string, vendor bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != dir && skipSourceDir(d.Name(), vendor) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// pollSources returns a channel receiving a value every interval until ctx is
+// done.
+func pollSources(ctx context.Context, interval time.Duration) <-chan struct{} {
+	wake := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			select {
+			case wake <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return wake
+}
+
+// settle returns once wake has stayed quiet for delay, or ctx is done.
+func settle(ctx context.Context, wake <-chan struct{}, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+			timer.Reset(delay)
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+// snapshotSources records the state of the files under dir that extraction
+// depends on: .go files and the go.mod, go.sum and go.work files.
+func snapshotSources(dir string, vendor bool) (map[string]fileState, error) {
+	sources := make(map[string]fileState)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skipSourceDir(d.Name(), vendor) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isSource(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sources[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return sources, err
+}
+
+// skipSourceDir reports whether the directory name holds no sources. Like the
+// go command, watching skips testdata and directories starting with . or _,
+// and vendor unless vendored packages are extracted.
+func skipSourceDir(name string, vendor bool) bool {
+	return name == "testdata" || (name == "vendor" && !vendor) ||
+		strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// isSource reports whether extraction depends on files of this name.
+func isSource(name string) bool {
+	switch name {
+	case "go.mod", "go.sum", "go.work", "go.work.sum":
+		return true
+	}
+	return filepath.Ext(name) == ".go"
+}
+
+// changedSources returns the files added, removed or modified between two
+// snapshots.
+func changedSources(before, after map[string]fileState) []string {
+	var changed []string
+	for path, state := range after {
+		if old, ok := before[path]; !ok || old != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// describeChanges names the changed file, or counts the changed files.
+func describeChanges(changed []string) string {
+	if len(changed) == 1 {
+		return changed[0]
+	}
+	return fmt.Sprintf("%d files", len(changed))
+}
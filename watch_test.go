@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatalf("mkdir for %s failed: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s failed: %v", path, err)
+	}
+}
+
+func TestChangedSources(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.go")
+	edited := filepath.Join(dir, "pkg", "edited.go")
+	removed := filepath.Join(dir, "removed.go")
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/m\n")
+	writeFile(t, kept, "package m\n")
+	writeFile(t, edited, "package pkg\n")
+	writeFile(t, removed, "package m\n")
+
+	before, err := snapshotSources(dir, false)
+	if err != nil {
+		t.Fatalf("snapshotSources failed: %v", err)
+	}
+	if len(before) != 4 {
+		t.Fatalf("snapshot holds %d files, want 4: %v", len(before), before)
+	}
+
+	added := filepath.Join(dir, "added.go")
+	writeFile(t, added, "package m\n")
+	// Same size, but a later modification time
+	writeFile(t, edited, "package pk2\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(edited, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	// Neither of these is a source
+	writeFile(t, filepath.Join(dir, "README.md"), "# m\n")
+	writeFile(t, filepath.Join(dir, "testdata", "fixture.go"), "package fixture\n")
+
+	after, err := snapshotSources(dir, false)
+	if err != nil {
+		t.Fatalf("snapshotSources failed: %v", err)
+	}
+	changed := changedSources(before, after)
+	slices.Sort(changed)
+	want := []string{added, edited, removed}
+	slices.Sort(want)
+	if !slices.Equal(changed, want) {
+		t.Errorf("changedSources = %v, want %v", changed, want)
+	}
+	if got := describeChanges(changed); got != "3 files" {
+		t.Errorf("describeChanges = %q", got)
+	}
+	if got := changedSources(after, after); len(got) != 0 {
+		t.Errorf("an unchanged snapshot reports %v", got)
+	}
+}
+
+func TestNotifySources(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wake, err := notifySources(ctx, dir, false)
+	if err != nil {
+		t.Skipf("file system notifications are unavailable: %v", err)
+	}
+
+	expectWake := func(what string) {
+		t.Helper()
+		select {
+		case <-wake:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notification after %s", what)
+		}
+		settle(ctx, wake, settleDelay)
+	}
+
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	expectWake("editing a file")
+
+	// Directories created after the watch started are watched too
+	if err := os.Mkdir(filepath.Join(dir, "pkg"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	expectWake("creating a directory")
+	writeFile(t, filepath.Join(dir, "pkg", "pkg.go"), "package pkg\n")
+	expectWake("adding a file to a new directory")
+
+	if err := os.Remove(filepath.Join(dir, "pkg", "pkg.go")); err != nil {
+		t.Fatal(err)
+	}
+	expectWake("removing a file")
+}